@@ -0,0 +1,149 @@
+package connectbox
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs is a set of cache TTLs for getter functions, that
+// suits most monitoring tools. Settings rarely change, while signal and
+// client data should stay fresh.
+var DefaultCacheTTLs = map[string]time.Duration{
+	FnGlobalSettings:        time.Hour,
+	FnCMSystemInfo:          time.Minute,
+	FnStatus:                10 * time.Second,
+	FnDownstreamTable:       10 * time.Second,
+	FnUpstreamTable:         10 * time.Second,
+	FnSignalTable:           5 * time.Second,
+	FnEventLogTable:         30 * time.Second,
+	FnLANSetting:            5 * time.Minute,
+	FnWANSetting:            5 * time.Minute,
+	FnLANUserTable:          10 * time.Second,
+	FnCMState:               5 * time.Second,
+	FnCMStatus:              time.Minute,
+	FnWirelessBasic1:        5 * time.Minute,
+	FnWirelessGuestNetwork1: 5 * time.Minute,
+}
+
+// WithCache enables caching of getter responses. TTLs are set per function
+// code, functions without TTL are not cached. Concurrent requests for
+// the same function share one request to the router. The cache is
+// cleared after any setter request.
+func WithCache(ttls map[string]time.Duration) Option {
+	return func(z *Client) {
+		z.cache = newResponseCache(ttls)
+	}
+}
+
+// responseCache keeps raw getter responses by function code.
+type responseCache struct {
+	ttls map[string]time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	calls   map[string]*cacheCall
+	// gen is increased on invalidation, so responses requested before
+	// it are not saved
+	gen int
+}
+
+type cacheEntry struct {
+	resp    string
+	expires time.Time
+}
+
+// cacheCall is an in-flight request, that other callers can wait for.
+type cacheCall struct {
+	done chan struct{}
+	resp string
+	err  error
+}
+
+func newResponseCache(ttls map[string]time.Duration) *responseCache {
+	c := &responseCache{
+		ttls:    make(map[string]time.Duration, len(ttls)),
+		now:     time.Now,
+		entries: map[string]cacheEntry{},
+		calls:   map[string]*cacheCall{},
+	}
+	for fn, ttl := range ttls {
+		c.ttls[fn] = ttl
+	}
+	return c
+}
+
+// get returns the cached response for the function, or calls `fetch`.
+// If there is a fetch for the function in progress, its result is used.
+// Errors and responses, that are not XML documents, are not cached.
+func (c *responseCache) get(
+	ctx context.Context,
+	fn string,
+	fetch func() (string, error),
+) (string, error) {
+	ttl, ok := c.ttls[fn]
+	if !ok || ttl <= 0 {
+		return fetch()
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[fn]; ok && c.now().Before(e.expires) {
+		c.mu.Unlock()
+		return e.resp, nil
+	}
+	if call, ok := c.calls[fn]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.resp, call.err
+		case <-ctx.Done():
+			return "", ctx.Err() //nolint:wrapcheck
+		}
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.calls[fn] = call
+	gen := c.gen
+	c.mu.Unlock()
+
+	call.resp, call.err = fetch()
+
+	c.mu.Lock()
+	delete(c.calls, fn)
+	if call.err == nil && gen == c.gen && isXMLDocument(call.resp) {
+		c.entries[fn] = cacheEntry{resp: call.resp, expires: c.now().Add(ttl)}
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.resp, call.err
+}
+
+// remove removes the cached response for the function, if it's still
+// the given one. It's used for responses, that failed to unmarshal.
+func (c *responseCache) remove(fn, resp string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[fn]; ok && e.resp == resp {
+		delete(c.entries, fn)
+	}
+}
+
+// invalidate removes all cached responses.
+func (c *responseCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]cacheEntry{}
+	c.gen++
+}
+
+// isXMLDocument checks if the response looks like an XML document, and not
+// like an empty body or an HTML page, that the router returns sometimes.
+func isXMLDocument(resp string) bool {
+	resp = strings.TrimSpace(resp)
+	head := strings.ToLower(resp[:min(len(resp), len("<!doctype html"))])
+	return strings.HasPrefix(head, "<") &&
+		!strings.HasPrefix(head, "<!doctype html") &&
+		!strings.HasPrefix(head, "<html")
+}
@@ -0,0 +1,286 @@
+package connectbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetCached(t *testing.T) {
+	t.Run("cached response", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithCache(map[string]time.Duration{FnMTUSize: time.Minute}))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Times(1).
+			Reply(http.StatusOK).
+			BodyString("<mtusize><size>1500</size></mtusize>")
+
+		for i := 0; i < 3; i++ {
+			var data MTUSize
+			require.NoError(t, client.Get(context.Background(), FnMTUSize, &data))
+			require.Equal(t, MTUSize{Size: "1500"}, data)
+		}
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("function without ttl", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithCache(map[string]time.Duration{FnMTUSize: time.Minute}))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=124$").
+			Times(2).
+			Reply(http.StatusOK).
+			BodyString("<ddns><enable>0</enable></ddns>")
+
+		var data DDNS
+		require.NoError(t, client.Get(context.Background(), FnDDNS, &data))
+		require.NoError(t, client.Get(context.Background(), FnDDNS, &data))
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("broken responses are not cached", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithCache(map[string]time.Duration{FnGlobalSettings: time.Hour}))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusOK)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusOK).
+			BodyString("<GlobalSettings><SwVersion>6.15")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Times(1).
+			Reply(http.StatusOK).
+			BodyString("<GlobalSettings><SwVersion>6.15</SwVersion></GlobalSettings>")
+
+		var data GlobalSettings
+		err = client.Get(context.Background(), FnGlobalSettings, &data)
+		require.ErrorContains(t, err, "unmarshal response")
+		err = client.Get(context.Background(), FnGlobalSettings, &data)
+		require.ErrorContains(t, err, "unmarshal response")
+		for i := 0; i < 2; i++ {
+			data = GlobalSettings{}
+			require.NoError(t, client.Get(context.Background(), FnGlobalSettings, &data))
+			require.Equal(t, "6.15", data.SwVersion)
+		}
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("invalidation after setter", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithCache(map[string]time.Duration{FnMTUSize: time.Minute}))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Reply(http.StatusOK).
+			BodyString("<mtusize><size>1500</size></mtusize>")
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301&wlSsid2g=home$").
+			Reply(http.StatusOK)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Reply(http.StatusOK).
+			BodyString("<mtusize><size>1400</size></mtusize>")
+
+		var data MTUSize
+		require.NoError(t, client.Get(context.Background(), FnMTUSize, &data))
+		require.Equal(t, "1500", data.Size)
+		_, err = client.SetRaw(context.Background(), FnSetWirelessBasic, [][2]string{{"wlSsid2g", "home"}})
+		require.NoError(t, err)
+		require.NoError(t, client.Get(context.Background(), FnMTUSize, &data))
+		require.Equal(t, "1400", data.Size)
+		require.True(t, gock.IsDone())
+	})
+}
+
+func TestResponseCache(t *testing.T) {
+	t.Run("expiration", func(t *testing.T) {
+		c := newResponseCache(map[string]time.Duration{
+			FnGlobalSettings: time.Hour,
+			FnSignalTable:    5 * time.Second,
+		})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		c.now = func() time.Time { return now }
+
+		calls := map[string]int{}
+		get := func(fn string) {
+			_, err := c.get(context.Background(), fn, func() (string, error) {
+				calls[fn]++
+				return "<resp/>", nil
+			})
+			require.NoError(t, err)
+		}
+
+		get(FnGlobalSettings)
+		get(FnSignalTable)
+		now = now.Add(10 * time.Second)
+		get(FnGlobalSettings)
+		get(FnSignalTable)
+
+		require.Equal(t, map[string]int{
+			FnGlobalSettings: 1,
+			FnSignalTable:    2,
+		}, calls)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		c := newResponseCache(map[string]time.Duration{FnSignalTable: time.Minute})
+
+		_, err := c.get(context.Background(), FnSignalTable, func() (string, error) {
+			return "", errors.New("timeout")
+		})
+		require.EqualError(t, err, "timeout")
+
+		resp, err := c.get(context.Background(), FnSignalTable, func() (string, error) {
+			return "<resp/>", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "<resp/>", resp)
+	})
+
+	t.Run("concurrent fetches", func(t *testing.T) {
+		c := newResponseCache(map[string]time.Duration{FnLANUserTable: time.Minute})
+
+		release := make(chan struct{})
+		var mu sync.Mutex
+		calls := 0
+		fetch := func() (string, error) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			<-release
+			return "<resp/>", nil
+		}
+
+		var wg sync.WaitGroup
+		results := make(chan string, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := c.get(context.Background(), FnLANUserTable, fetch)
+				if err == nil {
+					results <- resp
+				}
+			}()
+		}
+		// Wait until all callers either fetch or wait for the fetch
+		require.Eventually(t, func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.calls[FnLANUserTable] != nil
+		}, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		close(results)
+
+		require.Equal(t, 1, calls)
+		for resp := range results {
+			require.Equal(t, "<resp/>", resp)
+		}
+	})
+
+	t.Run("waiting cancelled", func(t *testing.T) {
+		c := newResponseCache(map[string]time.Duration{FnLANUserTable: time.Minute})
+
+		release := make(chan struct{})
+		defer close(release)
+		go c.get(context.Background(), FnLANUserTable, func() (string, error) { //nolint:errcheck
+			<-release
+			return "<resp/>", nil
+		})
+		require.Eventually(t, func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.calls[FnLANUserTable] != nil
+		}, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := c.get(ctx, FnLANUserTable, func() (string, error) {
+			return "", errors.New("must not be called")
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("invalidation during fetch", func(t *testing.T) {
+		c := newResponseCache(map[string]time.Duration{FnMTUSize: time.Minute})
+
+		resp, err := c.get(context.Background(), FnMTUSize, func() (string, error) {
+			c.invalidate()
+			return "<old/>", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "<old/>", resp)
+
+		resp, err = c.get(context.Background(), FnMTUSize, func() (string, error) {
+			return "<new/>", nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, "<new/>", resp)
+	})
+}
+
+func TestIsXMLDocument(t *testing.T) {
+	testCases := []struct {
+		resp string
+		want bool
+	}{
+		{resp: "", want: false},
+		{resp: " \n", want: false},
+		{resp: "Unauthorized", want: false},
+		{resp: "<!DOCTYPE html><html></html>", want: false},
+		{resp: "<HTML><body></body></HTML>", want: false},
+		{resp: "<mtusize><size>1500</size></mtusize>", want: true},
+		{resp: "\n<?xml version=\"1.0\"?><a/>", want: true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.resp, func(t *testing.T) {
+			require.Equal(t, tt.want, isXMLDocument(tt.resp))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package connectbox
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Capabilities is a set of features supported by the router firmware.
+type Capabilities struct {
+	SwVersion  string
+	Model      string
+	OperatorID string
+	// Flags of the features, that are hidden in the web interface
+	// by the operator
+	HideRemoteAccess          bool
+	HideModemMode             bool
+	HideCustomerDHCPLANChange bool
+	ShowDDNS                  bool
+	// Functions is a set of getter function codes, that return data
+	Functions map[string]bool
+}
+
+// probeFunctions is a list of getters, that are checked by Capabilities.
+// Getters with side effects (e.g. site survey, that starts a scan), and
+// the ones used by login are not probed.
+var probeFunctions = []string{
+	FnCMSystemInfo,
+	FnMultilang,
+	FnStatus,
+	FnConfiguration,
+	FnDownstreamTable,
+	FnUpstreamTable,
+	FnSignalTable,
+	FnEventLogTable,
+	FnFirewallLogTable,
+	FnLangsetlist,
+	FnLANSetting,
+	FnDHCPv6Info,
+	FnBasicDHCP,
+	FnWANSetting,
+	FnIPFiltering,
+	FnIPv6filtering,
+	FnPortTrigger,
+	FnWebFilter,
+	FnIPv6WebFilter,
+	FnMACFiltering,
+	FnForwarding,
+	FnLANUserTable,
+	FnDDNS,
+	FnRemoteAccess,
+	FnMTUSize,
+	FnCMState,
+	FnWiredState1,
+	FnWiredState2,
+	FnCMStatus,
+	FnEthFlaplist,
+	FnWirelessBasic1,
+	FnWirelessWmm,
+	FnWirelessGuestNetwork1,
+	FnCMWirelessWPS1,
+	FnCMWirelessAccessControl,
+	FnChannelMap,
+	FnWirelessBasic2,
+	FnWirelessGuestNetwork2,
+	FnWirelessClient,
+	FnCMWirelessWPS2,
+	FnDefaultValue,
+	FnWIFIState,
+}
+
+// Capabilities probes the router: reads the firmware details from global
+// settings, and checks which getters return data. It sends a request for
+// every known getter, so the result should be reused. Login is required.
+func (z *Client) Capabilities(ctx context.Context) (*Capabilities, error) {
+	var settings GlobalSettings
+	if err := z.getUncached(ctx, FnGlobalSettings, &settings); err != nil {
+		return nil, fmt.Errorf("get global settings: %w", err)
+	}
+
+	caps := &Capabilities{
+		SwVersion:                 settings.SwVersion,
+		Model:                     settings.ConfigVenderModel,
+		OperatorID:                settings.OperatorID,
+		HideRemoteAccess:          isTrue(settings.HideRemoteAccess),
+		HideModemMode:             isTrue(settings.HideModemMode),
+		HideCustomerDHCPLANChange: isTrue(settings.HideCustomerDHCPLANChange),
+		ShowDDNS:                  isTrue(settings.ShowDDNS),
+		Functions:                 map[string]bool{FnGlobalSettings: true},
+	}
+	for _, fn := range probeFunctions {
+		resp, err := z.xmlRequest(ctx, xmlGetter, fn, xmlArgs{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err() //nolint:wrapcheck
+			}
+			// Unsupported functions fail in different ways, so any
+			// error means no support
+			continue
+		}
+		if hasXMLData(resp) {
+			caps.Functions[fn] = true
+		}
+	}
+	return caps, nil
+}
+
+// Supports checks if the getter function returns data.
+func (c *Capabilities) Supports(fn string) bool {
+	return c.Functions[fn]
+}
+
+// Sections returns names of the config sections, that can be read from
+// the router. They can be passed to Snapshot to skip unsupported ones.
+// Note that Snapshot reads all sections if the list is empty.
+func (c *Capabilities) Sections() []string {
+	var sections []string
+	for _, s := range configSections {
+		if c.Supports(s.getter) {
+			sections = append(sections, s.name)
+		}
+	}
+	return sections
+}
+
+// hasXMLData checks if the XML document has any data, i.e. the root
+// element has child elements or text. For example, both empty response
+// and <porttrigger></porttrigger> have no data.
+func hasXMLData(s string) bool {
+	d := xml.NewDecoder(strings.NewReader(s))
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return false
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth > 0 {
+				return true
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth > 0 && len(strings.TrimSpace(string(t))) > 0 {
+				return true
+			}
+		}
+	}
+}
+
+// isTrue parses boolean flags from global settings, which are either
+// True/False or 1/0.
+func isTrue(s string) bool {
+	return strings.EqualFold(s, "true") || s == "1"
+}
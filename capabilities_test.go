@@ -0,0 +1,148 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Capabilities(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusOK).
+			BodyString(`<GlobalSettings>
+				<SwVersion>CH7465LG-NCIP-6.15.30-1p3-1-NOSH</SwVersion>
+				<ConfigVenderModel>CH7465LG</ConfigVenderModel>
+				<HideRemoteAccess>True</HideRemoteAccess>
+				<HideModemMode>False</HideModemMode>
+				<HideCustomerDhcpLanChange>0</HideCustomerDhcpLanChange>
+				<ShowDDNS>1</ShowDDNS>
+				<OperatorId>ZIGGO</OperatorId>
+			</GlobalSettings>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Reply(http.StatusOK).
+			BodyString("<mtusize><size>1500</size></mtusize>")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=100$").
+			Reply(http.StatusOK).
+			BodyString("<LanSetting><Ipv4><lanip>192.168.0.1</lanip></Ipv4></LanSetting>")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=113$").
+			Reply(http.StatusOK).
+			BodyString("<porttrigger>\n</porttrigger>")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=10$").
+			Reply(http.StatusInternalServerError)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			Persist().
+			Reply(http.StatusOK)
+
+		caps, err := client.Capabilities(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, &Capabilities{
+			SwVersion:        "CH7465LG-NCIP-6.15.30-1p3-1-NOSH",
+			Model:            "CH7465LG",
+			OperatorID:       "ZIGGO",
+			HideRemoteAccess: true,
+			ShowDDNS:         true,
+			Functions: map[string]bool{
+				FnGlobalSettings: true,
+				FnMTUSize:        true,
+				FnLANSetting:     true,
+			},
+		}, caps)
+		require.True(t, caps.Supports(FnMTUSize))
+		require.False(t, caps.Supports(FnPortTrigger))
+		require.False(t, caps.Supports(FnDownstreamTable))
+		require.Equal(t, []string{SectionLAN, SectionMTU, SectionGlobalSettings}, caps.Sections())
+	})
+
+	t.Run("no global settings", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusForbidden)
+
+		_, err = client.Capabilities(context.Background())
+		require.EqualError(t, err, "get global settings: get response: invalid response status: 403")
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusOK).
+			Map(func(resp *http.Response) *http.Response {
+				cancel()
+				return resp
+			}).
+			BodyString("<GlobalSettings></GlobalSettings>")
+
+		_, err = client.Capabilities(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestHasXMLData(t *testing.T) {
+	testCases := []struct {
+		data string
+		want bool
+	}{
+		{data: "", want: false},
+		{data: "<a></a>", want: false},
+		{data: "<a>\n\t</a>", want: false},
+		{data: `<?xml version="1.0"?><a/>`, want: false},
+		{data: "<a>1</a>", want: true},
+		{data: "<a><b></b></a>", want: true},
+		{data: "not xml", want: false},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.data, func(t *testing.T) {
+			require.Equal(t, tt.want, hasXMLData(tt.data))
+		})
+	}
+}
+
+func FuzzHasXMLData(f *testing.F) {
+	f.Add("<a><b>1</b></a>")
+	f.Add("<porttrigger></porttrigger>")
+	f.Fuzz(func(t *testing.T, s string) {
+		_ = hasXMLData(s)
+	})
+}
@@ -0,0 +1,136 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// euroDocsisSymbolRate is a symbol rate of EuroDOCSIS downstream channels
+// (8 MHz wide), symbols per second. Downstream table doesn't report it.
+const euroDocsisSymbolRate = 6.952e6
+
+// Capacity is an estimated line capacity.
+type Capacity struct {
+	Downstream DirectionCapacity
+	Upstream   DirectionCapacity
+}
+
+// DirectionCapacity is an estimated capacity of all bonded channels
+// in one direction.
+type DirectionCapacity struct {
+	Channels []ChannelCapacity
+	// Raw is a sum of raw bit rates of all channels, bits per second
+	Raw float64
+	// Provisioned is the max traffic rate from the service flows, bits
+	// per second
+	Provisioned float64
+}
+
+// ChannelCapacity is a raw bit rate of a single channel.
+type ChannelCapacity struct {
+	ChannelID  string
+	Modulation Modulation
+	// SymbolRate is in symbols per second, it's 0 for OFDM channels
+	SymbolRate float64
+	// BitRate is in bits per second, it's 0 if the modulation or the symbol
+	// rate is unknown, or if it's an OFDM channel, which doesn't have
+	// a single symbol rate
+	BitRate float64
+}
+
+// Utilization returns the part of raw capacity used by the provisioned
+// rate, or 0 if raw capacity is unknown.
+func (c DirectionCapacity) Utilization() float64 {
+	if c.Raw == 0 {
+		return 0
+	}
+	return c.Provisioned / c.Raw
+}
+
+// Capacity reads channel tables and service flows, and estimates
+// theoretical and provisioned throughput.
+func (z *Client) Capacity(ctx context.Context) (*Capacity, error) {
+	var ds DownstreamTable
+	if err := z.Get(ctx, FnDownstreamTable, &ds); err != nil {
+		return nil, fmt.Errorf("get downstream table: %w", err)
+	}
+	var us UpstreamTable
+	if err := z.Get(ctx, FnUpstreamTable, &us); err != nil {
+		return nil, fmt.Errorf("get upstream table: %w", err)
+	}
+	var st CMStatus
+	if err := z.Get(ctx, FnCMStatus, &st); err != nil {
+		return nil, fmt.Errorf("get cm status: %w", err)
+	}
+	return NewCapacity(&ds, &us, &st), nil
+}
+
+// NewCapacity estimates line capacity. Raw bit rate of each channel is
+// calculated as symbol rate multiplied by bits per symbol of the channel
+// modulation, and doesn't take FEC and protocol overhead into account.
+func NewCapacity(ds *DownstreamTable, us *UpstreamTable, st *CMStatus) *Capacity {
+	c := &Capacity{}
+
+	for _, d := range ds.Downstreams {
+		ch := channelCapacity(d.Chid, d.Mod, euroDocsisSymbolRate)
+		c.Downstream.Channels = append(c.Downstream.Channels, ch)
+		c.Downstream.Raw += ch.BitRate
+	}
+
+	for _, u := range us.Upstreams {
+		// Upstream symbol rate is reported in Msym/s. It's empty for OFDMA
+		// channels, and the bit rate of such channels is unknown
+		srate, err := strconv.ParseFloat(u.Srate, 64)
+		if err != nil {
+			srate = 0
+		}
+		ch := channelCapacity(u.Usid, u.Mod, srate*1e6)
+		c.Upstream.Channels = append(c.Upstream.Channels, ch)
+		c.Upstream.Raw += ch.BitRate
+	}
+
+	tier := st.SpeedTier()
+	c.Downstream.Provisioned = float64(tier.Down)
+	c.Upstream.Provisioned = float64(tier.Up)
+
+	return c
+}
+
+func channelCapacity(id string, mod Modulation, srate float64) ChannelCapacity {
+	if mod.IsOFDM() {
+		return ChannelCapacity{ChannelID: id, Modulation: mod}
+	}
+	return ChannelCapacity{
+		ChannelID:  id,
+		Modulation: mod,
+		SymbolRate: srate,
+		BitRate:    srate * float64(mod.BitsPerSymbol()),
+	}
+}
+
+// SpeedTier is a subscribed speed, bits per second.
+type SpeedTier struct {
+	Down int
+	Up   int
+}
+
+// String returns speed tier in Mbit/s, e.g. "1000/50 Mbit/s".
+func (t SpeedTier) String() string {
+	return fmt.Sprintf("%g/%g Mbit/s", float64(t.Down)/1e6, float64(t.Up)/1e6)
+}
+
+// SpeedTier returns the provisioned speed, which is the highest max traffic
+// rate of the service flows in each direction.
+func (s *CMStatus) SpeedTier() SpeedTier {
+	var t SpeedTier
+	for _, sf := range s.ServiceFlows {
+		switch sf.Direction {
+		case FlowDownstream:
+			t.Down = max(t.Down, sf.PMaxTrafficRate)
+		case FlowUpstream:
+			t.Up = max(t.Up, sf.PMaxTrafficRate)
+		}
+	}
+	return t
+}
@@ -0,0 +1,166 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Capacity(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=10$").
+			Reply(http.StatusOK).
+			BodyString(`<downstream_table><downstream>
+				<mod>256qam</mod><chid>1</chid>
+			</downstream></downstream_table>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=11$").
+			Reply(http.StatusOK).
+			BodyString(`<upstream_table><upstream>
+				<usid>2</usid><srate>5.120</srate><mod>64qam</mod>
+			</upstream></upstream_table>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=144$").
+			Reply(http.StatusOK).
+			BodyString(`<cmstatus><serviceflow>
+				<Sfid>1</Sfid><direction>2</direction>
+				<pMaxTrafficRate>30000000</pMaxTrafficRate>
+			</serviceflow></cmstatus>`)
+
+		c, err := client.Capacity(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, 55.616e6, c.Downstream.Raw)
+		require.Equal(t, 30.72e6, c.Upstream.Raw)
+		require.Equal(t, 30e6, c.Upstream.Provisioned)
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=10$").
+			Reply(http.StatusInternalServerError)
+
+		_, err = client.Capacity(context.Background())
+		require.ErrorContains(t, err, "get downstream table")
+	})
+}
+
+func TestNewCapacity(t *testing.T) {
+	t.Run("bonded channels", func(t *testing.T) {
+		ds := &DownstreamTable{Downstreams: []DownstreamTableDownstream{
+			{Chid: "1", Mod: Modulation256QAM},
+			{Chid: "2", Mod: Modulation64QAM},
+			{Chid: "3", Mod: ModulationOFDM},
+		}}
+		us := &UpstreamTable{Upstreams: []UpstreamTableUpstream{
+			{Usid: "1", Srate: "5.120", Mod: Modulation64QAM},
+			{Usid: "2", Srate: "2.560", Mod: Modulation16QAM},
+		}}
+		st := &CMStatus{ServiceFlows: []CMStatusServiceFlow{
+			{Sfid: "1", Direction: FlowDownstream, PMaxTrafficRate: 100000000},
+			{Sfid: "2", Direction: FlowDownstream, PMaxTrafficRate: 1000000},
+			{Sfid: "3", Direction: FlowUpstream, PMaxTrafficRate: 30000000},
+		}}
+
+		c := NewCapacity(ds, us, st)
+		require.Equal(t, &Capacity{
+			Downstream: DirectionCapacity{
+				Channels: []ChannelCapacity{
+					{
+						ChannelID:  "1",
+						Modulation: Modulation256QAM,
+						SymbolRate: 6.952e6,
+						BitRate:    55.616e6,
+					},
+					{
+						ChannelID:  "2",
+						Modulation: Modulation64QAM,
+						SymbolRate: 6.952e6,
+						BitRate:    41.712e6,
+					},
+					{
+						ChannelID:  "3",
+						Modulation: ModulationOFDM,
+					},
+				},
+				Raw:         97.328e6,
+				Provisioned: 100e6,
+			},
+			Upstream: DirectionCapacity{
+				Channels: []ChannelCapacity{
+					{
+						ChannelID:  "1",
+						Modulation: Modulation64QAM,
+						SymbolRate: 5.12e6,
+						BitRate:    30.72e6,
+					},
+					{
+						ChannelID:  "2",
+						Modulation: Modulation16QAM,
+						SymbolRate: 2.56e6,
+						BitRate:    10.24e6,
+					},
+				},
+				Raw:         40.96e6,
+				Provisioned: 30e6,
+			},
+		}, c)
+		require.InDelta(t, 0.732, c.Upstream.Utilization(), 0.001)
+	})
+
+	t.Run("unknown symbol rate", func(t *testing.T) {
+		us := &UpstreamTable{Upstreams: []UpstreamTableUpstream{
+			{Usid: "1", Srate: "5.120", Mod: Modulation64QAM},
+			{Usid: "2", Srate: "", Mod: Modulation64QAM},
+			{Usid: "3", Srate: "n/a", Mod: Modulation64QAM},
+		}}
+		c := NewCapacity(&DownstreamTable{}, us, &CMStatus{})
+		require.Equal(t, []ChannelCapacity{
+			{ChannelID: "1", Modulation: Modulation64QAM, SymbolRate: 5.12e6, BitRate: 30.72e6},
+			{ChannelID: "2", Modulation: Modulation64QAM},
+			{ChannelID: "3", Modulation: Modulation64QAM},
+		}, c.Upstream.Channels)
+		require.Equal(t, 30.72e6, c.Upstream.Raw)
+	})
+}
+
+func TestDirectionCapacity_Utilization(t *testing.T) {
+	require.Equal(t, 0.0, DirectionCapacity{Provisioned: 1}.Utilization())
+	require.Equal(t, 0.5, DirectionCapacity{Raw: 2, Provisioned: 1}.Utilization())
+}
+
+func TestCMStatus_SpeedTier(t *testing.T) {
+	st := &CMStatus{ServiceFlows: []CMStatusServiceFlow{
+		{Sfid: "1", Direction: FlowDownstream, PMaxTrafficRate: 1000000000},
+		{Sfid: "2", Direction: FlowDownstream, PMaxTrafficRate: 128000},
+		{Sfid: "3", Direction: FlowUpstream, PMaxTrafficRate: 50000000},
+		{Sfid: "4", Direction: FlowDirection(0), PMaxTrafficRate: 1},
+	}}
+	tier := st.SpeedTier()
+	require.Equal(t, SpeedTier{Down: 1000000000, Up: 50000000}, tier)
+	require.Equal(t, "1000/50 Mbit/s", tier.String())
+}
@@ -0,0 +1,174 @@
+package connectbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces secrets in captures.
+const redacted = "REDACTED"
+
+// secretNames is a list of substrings of argument and XML element names,
+// that hold secrets, in lower case. E.g. setters send Wi-Fi keys as
+// wlPSkey2g, while getters return them as PreSharedKey2g.
+var secretNames = []string{"key", "password"}
+
+// secretElements matches XML elements, that hold secrets.
+var secretElements = regexp.MustCompile(
+	`<(\w*(?i:key|password)\w*)>[^<]*<`)
+
+// sessionID matches the session ID in the login response, e.g.
+// "successful;SID=123456".
+var sessionID = regexp.MustCompile(`SID=[^;&\s]*`)
+
+// Capture is a recorded XML API request. Secrets are redacted both in
+// arguments and in the response.
+type Capture struct {
+	Time time.Time `json:"time"`
+	// Path is either getter or setter endpoint
+	Path string      `json:"path"`
+	Fn   string      `json:"fn"`
+	Args [][2]string `json:"args,omitempty"`
+	// Status is a response status code, if it's not 200
+	Status int `json:"status,omitempty"`
+	// Error is a transport error, e.g. connection refused
+	Error    string        `json:"error,omitempty"`
+	Response string        `json:"response"`
+	Duration time.Duration `json:"duration"`
+}
+
+// WithCapture makes the client record every XML API request to w as JSON
+// lines, that can be attached to bug reports and replayed by WithReplay.
+// Writing is best effort, write errors don't fail requests.
+func WithCapture(w io.Writer) Option {
+	return func(z *Client) {
+		z.capture = json.NewEncoder(w)
+	}
+}
+
+// WithReplay makes the client serve XML API requests from the captures
+// without sending anything to the router. Captures for each function are
+// served in the recorded order, the last one is repeated.
+func WithReplay(captures []Capture) Option {
+	return func(z *Client) {
+		z.replay = newReplayer(captures)
+	}
+}
+
+// ReadCaptures reads captures written by WithCapture.
+func ReadCaptures(r io.Reader) ([]Capture, error) {
+	var captures []Capture
+	scanner := bufio.NewScanner(r)
+	// Responses may be much longer than the default limit
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var c Capture
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		captures = append(captures, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read captures: %w", err)
+	}
+	return captures, nil
+}
+
+// record writes the capture of the request.
+func (z *Client) record(start time.Time, path, fn string, args xmlArgs, resp string, err error) {
+	c := Capture{
+		Time:     start.UTC(),
+		Path:     path,
+		Fn:       fn,
+		Args:     redactArgs(args),
+		Response: redactResponse(resp),
+		Duration: time.Since(start),
+	}
+	var serr *StatusError
+	switch {
+	case errors.As(err, &serr):
+		c.Status = serr.Code
+	case err != nil:
+		c.Error = err.Error()
+	}
+	_ = z.capture.Encode(c)
+}
+
+// replayer serves recorded responses.
+type replayer struct {
+	mu        sync.Mutex
+	responses map[string][]Capture
+}
+
+func newReplayer(captures []Capture) *replayer {
+	r := &replayer{responses: map[string][]Capture{}}
+	for _, c := range captures {
+		key := c.Path + "?" + c.Fn
+		r.responses[key] = append(r.responses[key], c)
+	}
+	return r
+}
+
+// next returns the next recorded response for the function.
+func (r *replayer) next(path, fn string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := path + "?" + fn
+	queue := r.responses[key]
+	if len(queue) == 0 {
+		return "", fmt.Errorf("no recorded response for %s fun=%s", path, fn)
+	}
+	c := queue[0]
+	if len(queue) > 1 {
+		r.responses[key] = queue[1:]
+	}
+
+	switch {
+	case c.Status != 0:
+		return "", &StatusError{Code: c.Status}
+	case c.Error != "":
+		return "", errors.New(c.Error)
+	}
+	return c.Response, nil
+}
+
+func redactArgs(args xmlArgs) [][2]string {
+	if len(args) == 0 {
+		return nil
+	}
+	redactedArgs := make([][2]string, len(args))
+	for i, arg := range args {
+		if isSecretName(arg[0]) {
+			arg[1] = redacted
+		}
+		redactedArgs[i] = arg
+	}
+	return redactedArgs
+}
+
+// isSecretName checks if the argument with the name holds a secret.
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range secretNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactResponse(resp string) string {
+	resp = secretElements.ReplaceAllString(resp, "<${1}>"+redacted+"<")
+	return sessionID.ReplaceAllString(resp, "SID="+redacted)
+}
@@ -0,0 +1,221 @@
+package connectbox
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCapture(t *testing.T) {
+	defer gock.Off()
+
+	var buf bytes.Buffer
+	client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithCapture(&buf))
+	require.NoError(t, err)
+
+	gock.InterceptClient(client.http)
+
+	mockLogin()
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=300$").
+		Reply(http.StatusOK).
+		BodyString("<WirelessBasic><PreSharedKey2g>secret</PreSharedKey2g></WirelessBasic>")
+	gock.New("http://127.0.0.1").
+		Post(xmlSetter).
+		BodyString("fun=301").
+		Reply(http.StatusOK)
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=134$").
+		Reply(http.StatusInternalServerError)
+
+	ctx := context.Background()
+	require.NoError(t, client.Login(ctx))
+	var wifi WirelessBasic1
+	require.NoError(t, client.Get(ctx, FnWirelessBasic1, &wifi))
+	_, err = client.SetRaw(ctx, FnSetWirelessBasic, [][2]string{
+		{"BandMode", "1"},
+		{"PreSharedKey2g", "secret"},
+	})
+	require.NoError(t, err)
+	_, err = client.GetRaw(ctx, FnMTUSize, nil)
+	require.Error(t, err)
+	require.True(t, gock.IsDone())
+
+	captures, err := ReadCaptures(&buf)
+	require.NoError(t, err)
+	for i := range captures {
+		require.False(t, captures[i].Time.IsZero())
+		require.Positive(t, captures[i].Duration)
+		captures[i].Time = time.Time{}
+		captures[i].Duration = 0
+	}
+	require.Equal(t, []Capture{
+		{
+			Path:     xmlGetter,
+			Fn:       FnGlobalSettings,
+			Response: "<GlobalSettings><LockedOut>Disable</LockedOut></GlobalSettings>",
+		},
+		{
+			Path:     xmlSetter,
+			Fn:       FnLogin,
+			Args:     [][2]string{{"Username", "bob"}, {"Password", "REDACTED"}},
+			Response: "success;SID=REDACTED",
+		},
+		{
+			Path:     xmlGetter,
+			Fn:       FnWirelessBasic1,
+			Response: "<WirelessBasic><PreSharedKey2g>REDACTED</PreSharedKey2g></WirelessBasic>",
+		},
+		{
+			Path: xmlSetter,
+			Fn:   FnSetWirelessBasic,
+			Args: [][2]string{{"BandMode", "1"}, {"PreSharedKey2g", "REDACTED"}},
+		},
+		{
+			Path:   xmlGetter,
+			Fn:     FnMTUSize,
+			Status: http.StatusInternalServerError,
+		},
+	}, captures)
+}
+
+func TestWithCapture_WirelessSetter(t *testing.T) {
+	defer gock.Off()
+
+	var buf bytes.Buffer
+	client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithCapture(&buf))
+	require.NoError(t, err)
+	client.token = "token1"
+
+	gock.InterceptClient(client.http)
+
+	gock.New("http://127.0.0.1").
+		Post(xmlSetter).
+		BodyString("fun=301&.*wlPSkey2g=secret2g&wlPSkey5g=secret5g").
+		Reply(http.StatusOK)
+
+	cfg := &Config{
+		Version: ConfigVersion,
+		Wireless: &WirelessBasic1{
+			SSID2G:         "home",
+			PreSharedKey2G: "secret2g",
+			PreSharedKey5G: "secret5g",
+		},
+	}
+	_, err = client.Restore(context.Background(), cfg)
+	require.NoError(t, err)
+	require.True(t, gock.IsDone())
+	require.NotContains(t, buf.String(), "secret")
+
+	captures, err := ReadCaptures(&buf)
+	require.NoError(t, err)
+	require.Len(t, captures, 1)
+	args := map[string]string{}
+	for _, arg := range captures[0].Args {
+		args[arg[0]] = arg[1]
+	}
+	require.Equal(t, "home", args["wlSsid2g"])
+	require.Equal(t, redacted, args["wlPSkey2g"])
+	require.Equal(t, redacted, args["wlPSkey5g"])
+}
+
+func TestIsSecretName(t *testing.T) {
+	testCases := []struct {
+		name string
+		want bool
+	}{
+		{name: "Password", want: true},
+		{name: "wlPSkey2g", want: true},
+		{name: "PreSharedKey5g", want: true},
+		{name: "WifiKey", want: true},
+		{name: "Username", want: false},
+		{name: "wlSsid2g", want: false},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isSecretName(tt.name))
+		})
+	}
+}
+
+func TestWithReplay(t *testing.T) {
+	captures := []Capture{
+		{Path: xmlGetter, Fn: FnGlobalSettings, Response: "<GlobalSettings></GlobalSettings>"},
+		{Path: xmlSetter, Fn: FnLogin, Response: "success;SID=sid1"},
+		{Path: xmlGetter, Fn: FnMTUSize, Response: "<mtusize><size>1500</size></mtusize>"},
+		{Path: xmlGetter, Fn: FnMTUSize, Response: "<mtusize><size>1400</size></mtusize>"},
+		{Path: xmlGetter, Fn: FnCMState, Status: http.StatusForbidden},
+		{Path: xmlGetter, Fn: FnStatus, Error: "connection refused"},
+	}
+	// Nothing listens on the port, so all responses are replayed
+	client, err := NewClient("http://127.0.0.1:1", "bob", "qwerty", WithReplay(captures))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Login(ctx))
+	require.Equal(t, "sid1", client.getCookie(sessionIDName))
+
+	// Responses are served in order, the last one is repeated
+	for _, want := range []string{"1500", "1400", "1400"} {
+		var mtu MTUSize
+		require.NoError(t, client.Get(ctx, FnMTUSize, &mtu))
+		require.Equal(t, want, mtu.Size)
+	}
+
+	var state CMState
+	err = client.Get(ctx, FnCMState, &state)
+	var serr *StatusError
+	require.ErrorAs(t, err, &serr)
+	require.Equal(t, http.StatusForbidden, serr.Code)
+
+	_, err = client.GetRaw(ctx, FnStatus, nil)
+	require.EqualError(t, err, "connection refused")
+
+	_, err = client.GetRaw(ctx, FnLANSetting, nil)
+	require.EqualError(t, err, "no recorded response for /xml/getter.xml fun=100")
+}
+
+func TestReadCaptures(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		data := `{"time":"2023-09-20T14:40:41Z","path":"/xml/getter.xml","fn":"134",` +
+			`"response":"<mtusize/>","duration":1000000}` + "\n\n"
+		captures, err := ReadCaptures(strings.NewReader(data))
+		require.NoError(t, err)
+		require.Equal(t, []Capture{{
+			Time:     time.Date(2023, 9, 20, 14, 40, 41, 0, time.UTC),
+			Path:     xmlGetter,
+			Fn:       FnMTUSize,
+			Response: "<mtusize/>",
+			Duration: time.Millisecond,
+		}}, captures)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ReadCaptures(strings.NewReader("{}\nhello\n"))
+		require.ErrorContains(t, err, "line 2: invalid character")
+	})
+}
+
+func FuzzReadCaptures(f *testing.F) {
+	f.Add(`{"path":"/xml/getter.xml","fn":"134","response":"<mtusize/>"}`)
+	f.Add(`{"path":"/xml/getter.xml","fn":"10","status":500}` + "\n" +
+		`{"path":"/xml/setter.xml","fn":"15","error":"connection refused"}`)
+	f.Fuzz(func(t *testing.T, data string) {
+		captures, err := ReadCaptures(strings.NewReader(data))
+		if err != nil {
+			return
+		}
+		r := newReplayer(captures)
+		for _, c := range captures {
+			_, _ = r.next(c.Path, c.Fn)
+		}
+	})
+}
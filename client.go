@@ -3,7 +3,7 @@ package connectbox
 
 import (
 	"context"
-	"crypto/sha256"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -11,6 +11,8 @@ import (
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 )
 
 // List of cookie names.
@@ -32,11 +34,38 @@ type Client struct {
 	addr     string
 	token    string
 	username string
-	password string
+	// secret is the password as given by the user, it's encoded by
+	// encoder on each login
+	secret     string
+	encoder    PasswordEncoder
+	autoDetect bool
+	// firmwares maps firmware version prefixes to password encodings
+	// for auto-detection
+	firmwares map[string]PasswordEncoder
+	cache     *responseCache
+	retry     *RetryPolicy
+	capture   *json.Encoder
+	replay    *replayer
+	// lockedUntil is the end of the account lockout, Login is not
+	// attempted until then
+	lockedUntil time.Time
+	// rebootedAt is the time of the last reboot request, it's reset when
+	// WaitOnline finds the router restarted
+	rebootedAt time.Time
+
+	// mu serializes requests, because the token is updated after each one
+	mu sync.Mutex
+
+	// kmu guards the keepalive loop
+	kmu       sync.Mutex
+	keepalive *keepalive
 }
 
+// Option is an optional client setting.
+type Option func(*Client)
+
 // NewClient creates new ConnectBox client.
-func NewClient(addr, username, password string) (*Client, error) {
+func NewClient(addr, username, password string, opts ...Option) (*Client, error) {
 	if !strings.HasPrefix(addr, "http") {
 		addr = "http://" + addr
 	}
@@ -49,7 +78,8 @@ func NewClient(addr, username, password string) (*Client, error) {
 	z := Client{
 		addr:     strings.TrimSuffix(addr, "/"),
 		username: username,
-		password: hashPassword(password),
+		secret:   password,
+		encoder:  PasswordSHA256,
 	}
 
 	jar, err := cookiejar.New(nil)
@@ -63,29 +93,39 @@ func NewClient(addr, username, password string) (*Client, error) {
 			return http.ErrUseLastResponse
 		},
 	}
+	for _, opt := range opts {
+		opt(&z)
+	}
 
 	return &z, nil
 }
 
 // Login gets auth token and session ID for further interactions
-// with ConnectBox.
+// with ConnectBox. Login is not attempted if the account is locked out
+// after too many failed attempts, LockoutError is returned instead.
 func (z *Client) Login(ctx context.Context) error {
+	if remaining := time.Until(z.lockedUntil); remaining > 0 {
+		return &LockoutError{Remaining: remaining}
+	}
+
 	// Send a request just to set initial token
+	z.mu.Lock()
 	_, err := z.get(ctx, loginPage)
+	z.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("get initial token: %w", err)
 	}
-
-	args := xmlArgs{
-		{"Username", z.username},
-		{"Password", z.password},
+	settings := z.globalSettings(ctx)
+	if lerr := z.lockout(ctx, settings); lerr != nil {
+		return lerr
 	}
-	resp, err := z.xmlRequest(ctx, xmlSetter, FnLogin, args)
+
+	resp, err := z.login(ctx, settings)
 	if err != nil {
-		return fmt.Errorf("xml request: %w", err)
+		return err
 	}
 	if !strings.HasPrefix(resp, "success") {
-		return fmt.Errorf("invalid response: %s", resp)
+		return z.loginFailure(ctx, resp)
 	}
 
 	var sid string
@@ -108,25 +148,56 @@ func (z *Client) Login(ctx context.Context) error {
 }
 
 // Logout closes current session. This is important because ConnectBox
-// is a single user device.
+// is a single user device. Keepalive loop is stopped.
 func (z *Client) Logout(ctx context.Context) error {
+	z.stopKeepalive(ctx)
 	_, err := z.xmlRequest(ctx, xmlSetter, FnLogout, xmlArgs{})
-	return err
+	if err != nil {
+		return err
+	}
+	z.setCookie(sessionIDName, "")
+	return nil
 }
 
 // Get sends a request to getter.xml endpoint with `fn` function code, and
-// unmarshals the result into `out` variable.
+// unmarshals the result into `out` variable. Responses are taken from
+// the cache, if it's enabled with WithCache option.
 func (z *Client) Get(ctx context.Context, fn string, out any) error {
-	resp, err := z.xmlRequest(ctx, xmlGetter, fn, xmlArgs{})
+	fetch := func() (string, error) {
+		return z.xmlRequest(ctx, xmlGetter, fn, xmlArgs{})
+	}
+	var resp string
+	var err error
+	if z.cache != nil {
+		resp, err = z.cache.get(ctx, fn, fetch)
+	} else {
+		resp, err = fetch()
+	}
 	if err != nil {
 		return fmt.Errorf("get response: %w", err)
 	}
 	if err := xml.Unmarshal([]byte(resp), out); err != nil {
+		if z.cache != nil {
+			// Broken response must not be served until it expires
+			z.cache.remove(fn, resp)
+		}
 		return fmt.Errorf("unmarshal response: %w", err)
 	}
 	return nil
 }
 
+// GetRaw sends a request to getter.xml endpoint with `fn` function code
+// and ordered arguments, and returns the response as is.
+func (z *Client) GetRaw(ctx context.Context, fn string, args [][2]string) (string, error) {
+	return z.xmlRequest(ctx, xmlGetter, fn, args)
+}
+
+// SetRaw sends a request to setter.xml endpoint with `fn` function code
+// and ordered arguments, and returns the response as is.
+func (z *Client) SetRaw(ctx context.Context, fn string, args [][2]string) (string, error) {
+	return z.xmlRequest(ctx, xmlSetter, fn, args)
+}
+
 func (z *Client) getCookie(name string) string {
 	u, _ := url.Parse(z.addr)
 	for _, cookie := range z.http.Jar.Cookies(u) {
@@ -148,15 +219,61 @@ func (z *Client) xmlRequest(
 	fn string,
 	args xmlArgs,
 ) (string, error) {
-	// Token and function must be first arguments
-	args = append(
+	// Any setter may change the data returned by getters
+	if path == xmlSetter && z.cache != nil {
+		defer z.cache.invalidate()
+	}
+
+	attempts := 1
+	if z.retry != nil && (path == xmlGetter || z.retry.RetrySetters) {
+		attempts = max(z.retry.MaxAttempts, 1)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := z.send(ctx, path, fn, args)
+		if z.retry == nil || !z.retry.isBusy(ctx, path, resp, err) {
+			return resp, err
+		}
+		if attempt >= attempts {
+			if err == nil {
+				err = ErrBusy
+			}
+			return "", err
+		}
+		if err := z.retry.wait(ctx, attempt); err != nil {
+			return "", err
+		}
+	}
+}
+
+// send sends one XML API request.
+func (z *Client) send(ctx context.Context, path, fn string, args xmlArgs) (string, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if z.replay != nil {
+		return z.replay.next(path, fn)
+	}
+
+	// Token and function must be first arguments. The token is taken
+	// for each request, because it's updated after each one.
+	data := append(
 		xmlArgs{{"token", z.token}, {"fun", fn}},
 		args...,
-	)
-	return z.post(ctx, path, args.Encode())
+	).Encode()
+	start := time.Now()
+	resp, err := z.post(ctx, path, data)
+	if z.capture != nil {
+		z.record(start, path, fn, args, resp, err)
+	}
+	return resp, err
 }
 
 func (z *Client) get(ctx context.Context, path string) (string, error) {
+	// Pages are only requested to get the initial token
+	if z.replay != nil {
+		return "", nil
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, z.addr+path, nil)
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
@@ -167,8 +284,13 @@ func (z *Client) get(ctx context.Context, path string) (string, error) {
 		return "", fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
+
+	// Token must be updated after each request, the router may issue
+	// a new one even with an error response
+	z.token = z.getCookie(sessionTokenName)
+
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("invalid response status: %d", resp.StatusCode)
+		return "", &StatusError{Code: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -176,9 +298,6 @@ func (z *Client) get(ctx context.Context, path string) (string, error) {
 		return "", fmt.Errorf("read body: %w", err)
 	}
 
-	// Token must be updated after each request
-	z.token = z.getCookie(sessionTokenName)
-
 	return string(body), nil
 }
 
@@ -199,8 +318,13 @@ func (z *Client) post(ctx context.Context, path, data string) (string, error) {
 		return "", fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
+
+	// Token must be updated after each request, the router may issue
+	// a new one even with an error response
+	z.token = z.getCookie(sessionTokenName)
+
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("invalid response status: %d", resp.StatusCode)
+		return "", &StatusError{Code: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -208,19 +332,9 @@ func (z *Client) post(ctx context.Context, path, data string) (string, error) {
 		return "", fmt.Errorf("read body: %w", err)
 	}
 
-	// Token must be updated after each request
-	z.token = z.getCookie(sessionTokenName)
-
 	return string(body), nil
 }
 
-func hashPassword(p string) string {
-	h := sha256.New()
-	h.Write([]byte(p))
-	sum := h.Sum(nil)
-	return fmt.Sprintf("%x", sum)
-}
-
 // xmlArgs is a helper type for ConnectBox XML RPC, which requires ordered
 // url-encoded requests. For example, `token` field must be always at the
 // first place.
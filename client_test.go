@@ -15,9 +15,7 @@ func TestNewClient(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "http://127.0.0.1:8080", client.addr)
 		require.Equal(t, "bob", client.username)
-		require.Equal(t,
-			"65e84be33532fb784c48129675f9eff3a682b27168c0ea744b2cf58ee02337c5",
-			client.password)
+		require.Equal(t, "qwerty", client.secret)
 	})
 
 	t.Run("invalid address", func(t *testing.T) {
@@ -39,6 +37,7 @@ func TestClient_Login(t *testing.T) {
 			Get(loginPage).
 			Reply(http.StatusOK).
 			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockLockedOut(LockedOutDisabled)
 		gock.New("http://127.0.0.1").
 			Post(xmlSetter).
 			MatchHeader("Cookie", "sessionToken=token1").
@@ -82,6 +81,7 @@ func TestClient_Login(t *testing.T) {
 			Get(loginPage).
 			Reply(http.StatusOK).
 			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockLockedOut(LockedOutDisabled)
 		gock.New("http://127.0.0.1").
 			Post(xmlSetter).
 			MatchHeader("Cookie", "sessionToken=token1").
@@ -105,6 +105,7 @@ func TestClient_Login(t *testing.T) {
 			Get(loginPage).
 			Reply(http.StatusOK).
 			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockLockedOut(LockedOutDisabled)
 		gock.New("http://127.0.0.1").
 			Post(xmlSetter).
 			MatchHeader("Cookie", "sessionToken=token1").
@@ -113,9 +114,16 @@ func TestClient_Login(t *testing.T) {
 			Reply(http.StatusOK).
 			AddHeader("Set-Cookie", "sessionToken=token2; Path=/").
 			BodyString("fail")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("token=token2&fun=22$").
+			Reply(http.StatusOK).
+			BodyString("<fail><FailCount>2</FailCount></fail>")
+		mockLockedOut(LockedOutDisabled)
 
 		err = client.Login(context.Background())
-		require.ErrorContains(t, err, "invalid response")
+		require.EqualError(t, err, "invalid response: fail, failed attempts: 2")
+		require.True(t, gock.IsDone())
 	})
 
 	t.Run("missing sid in response", func(t *testing.T) {
@@ -130,6 +138,7 @@ func TestClient_Login(t *testing.T) {
 			Get(loginPage).
 			Reply(http.StatusOK).
 			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockLockedOut(LockedOutDisabled)
 		gock.New("http://127.0.0.1").
 			Post(xmlSetter).
 			MatchHeader("Cookie", "sessionToken=token1").
@@ -271,3 +280,46 @@ func TestClient_Get(t *testing.T) {
 		require.ErrorContains(t, err, "connection refused")
 	})
 }
+
+func TestClient_Raw(t *testing.T) {
+	t.Run("getter", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("token=token1&fun=134$").
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token2; Path=/").
+			BodyString("<mtusize><size>1500</size></mtusize>")
+
+		resp, err := client.GetRaw(context.Background(), FnMTUSize, nil)
+		require.NoError(t, err)
+		require.Equal(t, "<mtusize><size>1500</size></mtusize>", resp)
+		require.Equal(t, "token2", client.token)
+	})
+
+	t.Run("setter", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("token=token1&fun=301&wlSsid2g=home$").
+			Reply(http.StatusOK)
+
+		resp, err := client.SetRaw(context.Background(), FnSetWirelessBasic, [][2]string{{"wlSsid2g", "home"}})
+		require.NoError(t, err)
+		require.Equal(t, "", resp)
+	})
+}
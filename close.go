@@ -0,0 +1,48 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// closeTimeout is a maximum time to wait for logout on Close.
+var closeTimeout = 5 * time.Second
+
+var _ io.Closer = (*Client)(nil)
+
+// Close logs out if there is an open session, so the router doesn't keep
+// a dangling one, that blocks other users. Logout takes no longer than
+// a few seconds, so Close is safe to call on exit.
+func (z *Client) Close() error {
+	if z.getCookie(sessionIDName) == "" {
+		z.StopKeepalive()
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	if err := z.Logout(ctx); err != nil {
+		return fmt.Errorf("logout: %w", err)
+	}
+	return nil
+}
+
+// NotifyContext returns a copy of the context, that is cancelled when
+// the process receives SIGINT or SIGTERM, and a function, that stops
+// listening for signals and closes `c`. The function should be deferred
+// in main, so the session is closed both on signals and on normal exit:
+//
+//	ctx, closeClient := connectbox.NotifyContext(context.Background(), client)
+//	defer closeClient()
+func NotifyContext(ctx context.Context, c io.Closer) (context.Context, func() error) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	return ctx, func() error {
+		stop()
+		return c.Close() //nolint:wrapcheck
+	}
+}
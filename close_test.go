@@ -0,0 +1,108 @@
+package connectbox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Close(t *testing.T) {
+	t.Run("logout", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+		client.setCookie(sessionIDName, "sid1")
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("token=token1&fun=16$").
+			Times(1).
+			Reply(http.StatusOK)
+
+		require.NoError(t, client.Close())
+		require.True(t, gock.IsDone())
+
+		// Session is already closed
+		require.NoError(t, client.Close())
+	})
+
+	t.Run("no session", func(t *testing.T) {
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		require.NoError(t, client.Close())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			// Body must be read to detect closed connection
+			_, _ = io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+		}))
+		defer srv.Close()
+
+		timeout := closeTimeout
+		closeTimeout = 20 * time.Millisecond
+		defer func() { closeTimeout = timeout }()
+
+		client, err := NewClient(srv.URL, "bob", "qwerty")
+		require.NoError(t, err)
+		client.setCookie(sessionIDName, "sid1")
+
+		start := time.Now()
+		err = client.Close()
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestNotifyContext(t *testing.T) {
+	t.Run("normal exit", func(t *testing.T) {
+		c := &testCloser{}
+		ctx, closeFn := NotifyContext(context.Background(), c)
+		require.NoError(t, ctx.Err())
+		require.NoError(t, closeFn())
+		require.True(t, c.closed)
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("sending signals is not supported")
+		}
+
+		c := &testCloser{}
+		ctx, closeFn := NotifyContext(context.Background(), c)
+
+		p, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		require.NoError(t, p.Signal(os.Interrupt))
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("context is not cancelled")
+		}
+		require.NoError(t, closeFn())
+		require.True(t, c.closed)
+	})
+}
+
+type testCloser struct {
+	closed bool
+}
+
+func (c *testCloser) Close() error {
+	c.closed = true
+	return nil
+}
@@ -0,0 +1,308 @@
+package connectbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ConfigVersion is a version of the Config document format. It must be
+// increased on any incompatible change of the format.
+const ConfigVersion = 1
+
+// Config is a snapshot of the router configuration. It can be serialized
+// to JSON, stored, and restored later. Nil sections are not restored.
+type Config struct {
+	Version       int                    `json:"version"`
+	CreatedAt     time.Time              `json:"created_at"`
+	LAN           *LANSetting            `json:"lan,omitempty"`
+	DHCP          *BasicDHCP             `json:"dhcp,omitempty"`
+	WAN           *WANSetting            `json:"wan,omitempty"`
+	MTU           *MTUSize               `json:"mtu,omitempty"`
+	DDNS          *DDNS                  `json:"ddns,omitempty"`
+	IPFiltering   *IPFiltering           `json:"ip_filtering,omitempty"`
+	IPv6Filtering *IPv6Filtering         `json:"ipv6_filtering,omitempty"`
+	MACFiltering  *MACFiltering          `json:"mac_filtering,omitempty"`
+	Firewall      *WebFilter             `json:"firewall,omitempty"`
+	IPv6Firewall  *IPv6WebFilter         `json:"ipv6_firewall,omitempty"`
+	Forwarding    *Forwarding            `json:"forwarding,omitempty"`
+	Wireless      *WirelessBasic1        `json:"wireless,omitempty"`
+	GuestNetwork  *WirelessGuestNetwork1 `json:"guest_network,omitempty"`
+	// GlobalSettings is read-only, it's used to find changes made by
+	// the provider, like hidden web interface features
+	GlobalSettings *GlobalSettings `json:"global_settings,omitempty"`
+}
+
+// List of configuration section names.
+const (
+	SectionLAN            = "lan"
+	SectionDHCP           = "dhcp"
+	SectionWAN            = "wan"
+	SectionMTU            = "mtu"
+	SectionDDNS           = "ddns"
+	SectionIPFiltering    = "ip_filtering"
+	SectionIPv6Filtering  = "ipv6_filtering"
+	SectionMACFiltering   = "mac_filtering"
+	SectionFirewall       = "firewall"
+	SectionIPv6Firewall   = "ipv6_firewall"
+	SectionForwarding     = "forwarding"
+	SectionWireless       = "wireless"
+	SectionGuestNetwork   = "guest_network"
+	SectionGlobalSettings = "global_settings"
+)
+
+// ErrNotWritable is returned when writing a section, that is read-only,
+// or which setter arguments are not known.
+var ErrNotWritable = errors.New("section is not writable")
+
+// configSection describes how to read and write one part of Config.
+type configSection struct {
+	name   string
+	getter string
+	setter string
+	// field returns a pointer to the section field of the config,
+	// e.g. **LANSetting.
+	field func(c *Config) any
+	// args returns setter arguments for the section value. Setters expect
+	// other argument names than getters return, so only the sections with
+	// names known from the router web interface have a setter, other
+	// sections are backup-only.
+	args func(v any) xmlArgs
+}
+
+// configSections is a list of all config sections in the order of their
+// dependencies: network settings go first, because filters, forwarding
+// rules and wireless settings refer to LAN addresses.
+var configSections = []configSection{
+	{
+		name:   SectionLAN,
+		getter: FnLANSetting,
+		field:  func(c *Config) any { return &c.LAN },
+	},
+	{
+		name:   SectionDHCP,
+		getter: FnBasicDHCP,
+		field:  func(c *Config) any { return &c.DHCP },
+	},
+	{
+		// WAN settings are mostly lease and address status, that can't
+		// be written back
+		name:   SectionWAN,
+		getter: FnWANSetting,
+		field:  func(c *Config) any { return &c.WAN },
+	},
+	{
+		name:   SectionMTU,
+		getter: FnMTUSize,
+		field:  func(c *Config) any { return &c.MTU },
+	},
+	{
+		name:   SectionDDNS,
+		getter: FnDDNS,
+		field:  func(c *Config) any { return &c.DDNS },
+	},
+	{
+		name:   SectionIPFiltering,
+		getter: FnIPFiltering,
+		field:  func(c *Config) any { return &c.IPFiltering },
+	},
+	{
+		name:   SectionIPv6Filtering,
+		getter: FnIPv6filtering,
+		field:  func(c *Config) any { return &c.IPv6Filtering },
+	},
+	{
+		name:   SectionMACFiltering,
+		getter: FnMACFiltering,
+		field:  func(c *Config) any { return &c.MACFiltering },
+	},
+	{
+		name:   SectionFirewall,
+		getter: FnWebFilter,
+		field:  func(c *Config) any { return &c.Firewall },
+	},
+	{
+		name:   SectionIPv6Firewall,
+		getter: FnIPv6WebFilter,
+		field:  func(c *Config) any { return &c.IPv6Firewall },
+	},
+	{
+		// The router adds and removes rules one by one, while the getter
+		// returns UPnP mappings, so forwarding can't be restored as is
+		name:   SectionForwarding,
+		getter: FnForwarding,
+		field:  func(c *Config) any { return &c.Forwarding },
+	},
+	{
+		name:   SectionWireless,
+		getter: FnWirelessBasic1,
+		setter: FnSetWirelessBasic,
+		field:  func(c *Config) any { return &c.Wireless },
+		args:   wirelessArgs,
+	},
+	{
+		name:   SectionGuestNetwork,
+		getter: FnWirelessGuestNetwork1,
+		field:  func(c *Config) any { return &c.GuestNetwork },
+	},
+	{
+		name:   SectionGlobalSettings,
+		getter: FnGlobalSettings,
+		field:  func(c *Config) any { return &c.GlobalSettings },
+	},
+}
+
+// SectionResult is a result of restoring one section of Config.
+type SectionResult struct {
+	Section string
+	// Skipped is set for backup-only sections, that are not written
+	Skipped bool
+	Err     error
+}
+
+// Backup reads all configuration sections from the router.
+func (z *Client) Backup(ctx context.Context) (*Config, error) {
+	return z.Snapshot(ctx)
+}
+
+// Snapshot reads the given configuration sections from the router. All
+// sections are read if none are given.
+func (z *Client) Snapshot(ctx context.Context, sections ...string) (*Config, error) {
+	selected := map[string]bool{}
+	for _, name := range sections {
+		if _, ok := findSection(name); !ok {
+			return nil, fmt.Errorf("unknown section: %s", name)
+		}
+		selected[name] = true
+	}
+
+	cfg := &Config{
+		Version:   ConfigVersion,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, s := range configSections {
+		if len(selected) > 0 && !selected[s.name] {
+			continue
+		}
+		if err := z.getSection(ctx, s, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// Restore writes all non-empty sections of the config to the router.
+// All sections are tried even if some of them fail, the result for each
+// section is reported in the returned list. Backup-only sections, which
+// are all sections except the wireless one for now, are not written and
+// reported as skipped.
+func (z *Client) Restore(ctx context.Context, cfg *Config) ([]SectionResult, error) {
+	if cfg.Version != ConfigVersion {
+		return nil, fmt.Errorf("unsupported config version: %d", cfg.Version)
+	}
+
+	var results []SectionResult
+	var errs []error
+	for _, s := range configSections {
+		v := sectionValue(s, cfg)
+		if v == nil {
+			continue
+		}
+		if !s.writable() {
+			results = append(results, SectionResult{Section: s.name, Skipped: true})
+			continue
+		}
+		err := z.setSection(ctx, s, v)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		results = append(results, SectionResult{Section: s.name, Err: err})
+	}
+	return results, errors.Join(errs...)
+}
+
+// findSection returns a config section by its name.
+func findSection(name string) (configSection, bool) {
+	for _, s := range configSections {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return configSection{}, false
+}
+
+// getSection reads one section from the router into the config.
+func (z *Client) getSection(ctx context.Context, s configSection, cfg *Config) error {
+	if err := z.Get(ctx, s.getter, newSectionValue(s, cfg)); err != nil {
+		return fmt.Errorf("get %s: %w", s.name, err)
+	}
+	return nil
+}
+
+// writable checks if the section can be written to the router.
+func (s configSection) writable() bool {
+	return s.args != nil
+}
+
+// setSection writes one section value to the router.
+func (z *Client) setSection(ctx context.Context, s configSection, v any) error {
+	if !s.writable() {
+		return fmt.Errorf("set %s: %w", s.name, ErrNotWritable)
+	}
+	if _, err := z.xmlRequest(ctx, xmlSetter, s.setter, s.args(v)); err != nil {
+		return fmt.Errorf("set %s: %w", s.name, err)
+	}
+	return nil
+}
+
+// wirelessArgs returns FnSetWirelessBasic arguments, the names are the
+// ones sent by the router web interface.
+func wirelessArgs(v any) xmlArgs {
+	w := v.(*WirelessBasic1)
+	return xmlArgs{
+		{"wlBandMode2g", w.BSSEnable2G},
+		{"wlBandMode5g", w.BssEnable5G},
+		{"wlSsid2g", w.SSID2G},
+		{"wlSsid5g", w.SSID5G},
+		{"wlBandwidth2g", w.BandWidth2G},
+		{"wlBandwidth5g", w.BandWidth5G},
+		{"wlTxMode2g", w.TransmissionMode2G},
+		{"wlTxMode5g", w.TransmissionMode5G},
+		{"wlMCastRate2g", w.MulticastRate2G},
+		{"wlMCastRate5g", w.MulticastRate5G},
+		{"wlHiden2g", w.HideNetwork2G},
+		{"wlHiden5g", w.HideNetwork5G},
+		{"wlCoexistence", w.BSSCoexistence},
+		{"wlPSkey2g", w.PreSharedKey2G},
+		{"wlPSkey5g", w.PreSharedKey5G},
+		{"wlTxrate2g", w.TransmissionRate2G},
+		{"wlTxrate5g", w.TransmissionRate5G},
+		{"wlRekey2g", w.GroupRekeyInterval2G},
+		{"wlRekey5g", w.GroupRekeyInterval5G},
+		{"wlChannel2g", w.ChannelSetting2G},
+		{"wlChannel5g", w.ChannelSetting5G},
+		{"wlSecurity2g", w.SecurityMode2G},
+		{"wlSecurity5g", w.SecurityMode5G},
+		{"wlWpaalg2g", w.WpaAlgorithm2G},
+		{"wlWpaalg5g", w.WpaAlgorithm5G},
+	}
+}
+
+// sectionValue returns the section of the config, or nil if it's not set.
+func sectionValue(s configSection, cfg *Config) any {
+	v := reflect.ValueOf(s.field(cfg)).Elem()
+	if v.IsNil() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// newSectionValue allocates a new value for the section of the config,
+// and returns a pointer to it.
+func newSectionValue(s configSection, cfg *Config) any {
+	v := reflect.ValueOf(s.field(cfg)).Elem()
+	v.Set(reflect.New(v.Type().Elem()))
+	return v.Interface()
+}
@@ -0,0 +1,227 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Backup(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		for _, s := range configSections {
+			body := "<root/>"
+			if s.name == SectionMTU {
+				body = "<MTUSize><size>1500</size></MTUSize>"
+			}
+			gock.New("http://127.0.0.1").
+				Post(xmlGetter).
+				BodyString("fun=" + s.getter + "$").
+				Reply(http.StatusOK).
+				BodyString(body)
+		}
+
+		cfg, err := client.Backup(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, ConfigVersion, cfg.Version)
+		require.Equal(t, &MTUSize{Size: "1500"}, cfg.MTU)
+		require.Equal(t, &LANSetting{}, cfg.LAN)
+		require.Equal(t, &WirelessGuestNetwork1{}, cfg.GuestNetwork)
+	})
+
+	t.Run("failed section", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=100$").
+			Reply(http.StatusInternalServerError)
+
+		_, err = client.Backup(context.Background())
+		require.ErrorContains(t, err, "get lan")
+	})
+}
+
+func TestClient_Snapshot(t *testing.T) {
+	t.Run("selected sections", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Reply(http.StatusOK).
+			BodyString("<MTUSize><size>1500</size></MTUSize>")
+
+		cfg, err := client.Snapshot(context.Background(), SectionMTU)
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, &MTUSize{Size: "1500"}, cfg.MTU)
+		require.Nil(t, cfg.LAN)
+	})
+
+	t.Run("unknown section", func(t *testing.T) {
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+
+		_, err = client.Snapshot(context.Background(), "hello")
+		require.ErrorContains(t, err, "unknown section: hello")
+	})
+}
+
+func TestClient_Restore(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301&wlBandMode2g=1&wlBandMode5g=1&wlSsid2g=home&wlSsid5g=home5&").
+			Reply(http.StatusOK)
+
+		cfg := &Config{
+			Version: ConfigVersion,
+			Wireless: &WirelessBasic1{
+				BSSEnable2G: "1",
+				BssEnable5G: "1",
+				SSID2G:      "home",
+				SSID5G:      "home5",
+			},
+		}
+		results, err := client.Restore(context.Background(), cfg)
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, []SectionResult{{Section: SectionWireless}}, results)
+	})
+
+	t.Run("backup-only section", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301").
+			Reply(http.StatusOK)
+
+		cfg := &Config{
+			Version:  ConfigVersion,
+			WAN:      &WANSetting{},
+			MTU:      &MTUSize{Size: "1500"},
+			Wireless: &WirelessBasic1{SSID2G: "home"},
+		}
+		results, err := client.Restore(context.Background(), cfg)
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, []SectionResult{
+			{Section: SectionWAN, Skipped: true},
+			{Section: SectionMTU, Skipped: true},
+			{Section: SectionWireless},
+		}, results)
+	})
+
+	t.Run("failed section", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301").
+			Reply(http.StatusInternalServerError)
+
+		cfg := &Config{
+			Version:  ConfigVersion,
+			Wireless: &WirelessBasic1{SSID2G: "home"},
+		}
+		results, err := client.Restore(context.Background(), cfg)
+		require.ErrorContains(t, err, "set wireless")
+		require.Len(t, results, 1)
+		require.ErrorContains(t, results[0].Err, "invalid response status")
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+
+		_, err = client.Restore(context.Background(), &Config{Version: 100})
+		require.ErrorContains(t, err, "unsupported config version")
+	})
+}
+
+func TestWirelessArgs(t *testing.T) {
+	args := wirelessArgs(&WirelessBasic1{
+		BSSEnable2G:          "1",
+		SSID2G:               "home",
+		HideNetwork2G:        "2",
+		BSSCoexistence:       "1",
+		PreSharedKey2G:       "secret",
+		ChannelSetting2G:     "0",
+		CurrentChannel2G:     "6",
+		GroupRekeyInterval2G: "0",
+		SecurityMode2G:       "8",
+		WpaAlgorithm2G:       "2",
+	})
+	require.Equal(t, xmlArgs{
+		{"wlBandMode2g", "1"},
+		{"wlBandMode5g", ""},
+		{"wlSsid2g", "home"},
+		{"wlSsid5g", ""},
+		{"wlBandwidth2g", ""},
+		{"wlBandwidth5g", ""},
+		{"wlTxMode2g", ""},
+		{"wlTxMode5g", ""},
+		{"wlMCastRate2g", ""},
+		{"wlMCastRate5g", ""},
+		{"wlHiden2g", "2"},
+		{"wlHiden5g", ""},
+		{"wlCoexistence", "1"},
+		{"wlPSkey2g", "secret"},
+		{"wlPSkey5g", ""},
+		{"wlTxrate2g", ""},
+		{"wlTxrate5g", ""},
+		{"wlRekey2g", "0"},
+		{"wlRekey5g", ""},
+		{"wlChannel2g", "0"},
+		{"wlChannel5g", ""},
+		{"wlSecurity2g", "8"},
+		{"wlSecurity5g", ""},
+		{"wlWpaalg2g", "2"},
+		{"wlWpaalg5g", ""},
+	}, args)
+}
@@ -0,0 +1,136 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Drift is a list of changes in one configuration section compared
+// to the baseline.
+type Drift struct {
+	Section string
+	Changes []Change
+}
+
+// Notifier receives reports about configuration drift.
+type Notifier interface {
+	Notify(ctx context.Context, drifts []Drift) error
+}
+
+// NotifierFunc is an adapter to use ordinary functions as notifiers.
+type NotifierFunc func(ctx context.Context, drifts []Drift) error
+
+// Notify calls f(ctx, drifts).
+func (f NotifierFunc) Notify(ctx context.Context, drifts []Drift) error {
+	return f(ctx, drifts)
+}
+
+// DriftChecker periodically compares the router configuration with
+// the baseline, and reports the differences. This helps to find settings
+// silently changed by the provider's remote provisioning.
+type DriftChecker struct {
+	client   *Client
+	baseline *Config
+	notifier Notifier
+	partial  bool
+}
+
+// DriftOption is an optional drift checker setting.
+type DriftOption func(*DriftChecker)
+
+// WithPartialBaseline makes the checker compare only non-empty fields of
+// the baseline sections, so the baseline may contain just the settings
+// that matter. Empty fields and lists are not checked then, e.g. rules
+// added to an empty forwarding list are not reported.
+func WithPartialBaseline() DriftOption {
+	return func(d *DriftChecker) {
+		d.partial = true
+	}
+}
+
+// NewDriftChecker creates new drift checker. Only the sections that are
+// present in the baseline are checked, and they are compared in full, so
+// the baseline is usually taken with Client.Snapshot.
+func NewDriftChecker(
+	client *Client,
+	baseline *Config,
+	notifier Notifier,
+	opts ...DriftOption,
+) *DriftChecker {
+	d := &DriftChecker{
+		client:   client,
+		baseline: baseline,
+		notifier: notifier,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Check reads the current configuration and compares it with the baseline.
+func (d *DriftChecker) Check(ctx context.Context) ([]Drift, error) {
+	live := &Config{}
+	var drifts []Drift
+	for _, s := range configSections {
+		base := sectionValue(s, d.baseline)
+		if base == nil {
+			continue
+		}
+		if err := d.client.getSection(ctx, s, live); err != nil {
+			return nil, err
+		}
+		have := sectionValue(s, live)
+		if d.partial {
+			// Empty baseline fields take live values, so they are not
+			// compared
+			base = merge(have, base)
+		}
+		changes := diff(base, have)
+		if len(changes) == 0 {
+			continue
+		}
+		drifts = append(drifts, Drift{Section: s.name, Changes: changes})
+	}
+	return drifts, nil
+}
+
+// Run checks the configuration every `interval`, and sends found drifts
+// to the notifier. The same drifts are sent only once, until they change.
+// Failed checks and notifications are reported to onError, which may be
+// nil, and don't stop the checker. It stops only when the context is done.
+func (d *DriftChecker) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	report := func(err error) {
+		if onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+	}
+
+	var notified []Drift
+	for {
+		drifts, err := d.Check(ctx)
+		switch {
+		case err != nil:
+			report(fmt.Errorf("check: %w", err))
+		case len(drifts) == 0:
+			notified = nil
+		case !reflect.DeepEqual(drifts, notified):
+			if err := d.notifier.Notify(ctx, drifts); err != nil {
+				report(fmt.Errorf("notify: %w", err))
+				break
+			}
+			notified = drifts
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriftChecker_Check(t *testing.T) {
+	t.Run("drift", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=100$").
+			Reply(http.StatusOK).
+			BodyString("<LANSetting><DMZ>0</DMZ></LANSetting>")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=300$").
+			Reply(http.StatusOK).
+			BodyString(`<WirelessBasic1>
+				<ChannelSetting2G>0</ChannelSetting2G>
+				<CurrentChannel2G>11</CurrentChannel2G>
+			</WirelessBasic1>`)
+
+		baseline := &Config{
+			Version: ConfigVersion,
+			LAN:     &LANSetting{DMZ: "0"},
+			Wireless: &WirelessBasic1{
+				ChannelSetting2G: "6",
+				CurrentChannel2G: "6",
+			},
+		}
+		checker := NewDriftChecker(client, baseline, nil)
+
+		drifts, err := checker.Check(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, []Drift{{
+			Section: SectionWireless,
+			Changes: []Change{
+				{Path: "ChannelSetting2G", Old: "6", New: "0"},
+				{Path: "CurrentChannel2G", Old: "6", New: "11"},
+			},
+		}}, drifts)
+	})
+
+	t.Run("selected fields", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusOK).
+			BodyString(`<GlobalSettings>
+				<SwVersion>CH7465LG-NCIP-6.15.30-1p3-1-NOSH</SwVersion>
+				<HideModemMode>True</HideModemMode>
+				<LockedOut>Disable</LockedOut>
+			</GlobalSettings>`)
+
+		baseline := &Config{
+			Version:        ConfigVersion,
+			GlobalSettings: &GlobalSettings{HideModemMode: "False"},
+		}
+		checker := NewDriftChecker(client, baseline, nil, WithPartialBaseline())
+
+		drifts, err := checker.Check(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, []Drift{{
+			Section: SectionGlobalSettings,
+			Changes: []Change{{Path: "HideModemMode", Old: "False", New: "True"}},
+		}}, drifts)
+	})
+
+	t.Run("added forwarding rules", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=121$").
+			Persist().
+			Reply(http.StatusOK).
+			BodyString(`<Forwarding>
+				<LanIP>192.168.0.1</LanIP>
+				<UPnP>
+					<LanIPAddr>192.168.0.10</LanIPAddr>
+					<LanPort>22</LanPort>
+					<WanPort>2222</WanPort>
+					<Protocol>TCP</Protocol>
+				</UPnP>
+			</Forwarding>`)
+
+		baseline := &Config{
+			Version:    ConfigVersion,
+			Forwarding: &Forwarding{LANIP: "192.168.0.1"},
+		}
+
+		drifts, err := NewDriftChecker(client, baseline, nil).Check(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []Drift{{
+			Section: SectionForwarding,
+			Changes: []Change{
+				{Path: "UPnPs[0].LANIPAddr", Old: "", New: "192.168.0.10"},
+				{Path: "UPnPs[0].LANPort", Old: "", New: "22"},
+				{Path: "UPnPs[0].WANPort", Old: "", New: "2222"},
+				{Path: "UPnPs[0].Protocol", Old: "", New: "TCP"},
+			},
+		}}, drifts)
+
+		// Empty lists are not checked with a partial baseline
+		drifts, err = NewDriftChecker(client, baseline, nil, WithPartialBaseline()).
+			Check(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, drifts)
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=100$").
+			Reply(http.StatusInternalServerError)
+
+		baseline := &Config{
+			Version: ConfigVersion,
+			LAN:     &LANSetting{DMZ: "0"},
+		}
+		checker := NewDriftChecker(client, baseline, nil)
+
+		_, err = checker.Check(context.Background())
+		require.ErrorContains(t, err, "get lan")
+	})
+}
+
+func TestDriftChecker_Run(t *testing.T) {
+	defer gock.Off()
+
+	client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+	require.NoError(t, err)
+	client.token = "token1"
+
+	gock.InterceptClient(client.http)
+
+	settings := func(hideModemMode, showDDNS string) string {
+		return "<GlobalSettings><HideModemMode>" + hideModemMode + "</HideModemMode>" +
+			"<ShowDDNS>" + showDDNS + "</ShowDDNS></GlobalSettings>"
+	}
+	// Failed check, new drift, the same drift, changed drift
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=1$").
+		Reply(http.StatusInternalServerError)
+	for _, body := range []string{
+		settings("True", "True"),
+		settings("True", "True"),
+		settings("True", "False"),
+	} {
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusOK).
+			BodyString(body)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var notified [][]Drift
+	notifier := NotifierFunc(func(ctx context.Context, drifts []Drift) error {
+		notified = append(notified, drifts)
+		if len(notified) == 2 {
+			cancel()
+		}
+		return nil
+	})
+	baseline := &Config{
+		Version:        ConfigVersion,
+		GlobalSettings: &GlobalSettings{HideModemMode: "False", ShowDDNS: "True"},
+	}
+	checker := NewDriftChecker(client, baseline, notifier)
+
+	var errs []error
+	err = checker.Run(ctx, time.Millisecond, func(err error) {
+		errs = append(errs, err)
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.True(t, gock.IsDone())
+	require.Len(t, errs, 1)
+	require.ErrorContains(t, errs[0], "check: get global_settings")
+	require.Equal(t, [][]Drift{
+		{{
+			Section: SectionGlobalSettings,
+			Changes: []Change{{Path: "HideModemMode", Old: "False", New: "True"}},
+		}},
+		{{
+			Section: SectionGlobalSettings,
+			Changes: []Change{
+				{Path: "HideModemMode", Old: "False", New: "True"},
+				{Path: "ShowDDNS", Old: "True", New: "False"},
+			},
+		}},
+	}, notified)
+}
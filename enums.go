@@ -0,0 +1,251 @@
+package connectbox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Modulation is a channel modulation, e.g. "256qam".
+type Modulation string
+
+// List of known modulations.
+const (
+	ModulationQPSK    Modulation = "qpsk"
+	Modulation8QAM    Modulation = "8qam"
+	Modulation16QAM   Modulation = "16qam"
+	Modulation32QAM   Modulation = "32qam"
+	Modulation64QAM   Modulation = "64qam"
+	Modulation128QAM  Modulation = "128qam"
+	Modulation256QAM  Modulation = "256qam"
+	Modulation512QAM  Modulation = "512qam"
+	Modulation1024QAM Modulation = "1024qam"
+	Modulation2048QAM Modulation = "2048qam"
+	Modulation4096QAM Modulation = "4096qam"
+	ModulationOFDM    Modulation = "ofdm"
+	ModulationOFDMA   Modulation = "ofdma"
+)
+
+// modulationBits is a number of bits per symbol for each modulation.
+var modulationBits = map[Modulation]int{
+	ModulationQPSK:    2,
+	Modulation8QAM:    3,
+	Modulation16QAM:   4,
+	Modulation32QAM:   5,
+	Modulation64QAM:   6,
+	Modulation128QAM:  7,
+	Modulation256QAM:  8,
+	Modulation512QAM:  9,
+	Modulation1024QAM: 10,
+	Modulation2048QAM: 11,
+	Modulation4096QAM: 12,
+	ModulationOFDM:    0,
+	ModulationOFDMA:   0,
+}
+
+// ParseModulation parses modulation names in different notations, like
+// "256qam", "256QAM", "QAM256" or "qam_256".
+func ParseModulation(s string) (Modulation, error) {
+	norm := strings.ToLower(s)
+	norm = strings.NewReplacer(" ", "", "-", "", "_", "").Replace(norm)
+	if strings.HasPrefix(norm, "qam") {
+		norm = strings.TrimPrefix(norm, "qam") + "qam"
+	}
+	m := Modulation(norm)
+	if _, ok := modulationBits[m]; !ok {
+		return "", fmt.Errorf("unknown modulation: %q", s)
+	}
+	return m, nil
+}
+
+// UnmarshalText normalizes known modulations. Unknown values are kept
+// as is, so new firmware values don't break the whole response.
+func (m *Modulation) UnmarshalText(text []byte) error {
+	parsed, err := ParseModulation(string(text))
+	if err != nil {
+		*m = Modulation(text)
+		return nil
+	}
+	*m = parsed
+	return nil
+}
+
+// String returns modulation name.
+func (m Modulation) String() string {
+	return string(m)
+}
+
+// Known returns true for known modulations.
+func (m Modulation) Known() bool {
+	_, ok := modulationBits[m]
+	return ok
+}
+
+// BitsPerSymbol returns the number of bits carried by one symbol. It's 0
+// for unknown modulations and for OFDM channels, which use different
+// modulations on different subcarriers.
+func (m Modulation) BitsPerSymbol() int {
+	return modulationBits[m]
+}
+
+// IsOFDM returns true for DOCSIS 3.1 OFDM/OFDMA channels. QAM orders above
+// 256 are only used on OFDM subcarriers, so they are counted too.
+func (m Modulation) IsOFDM() bool {
+	return m == ModulationOFDM || m == ModulationOFDMA ||
+		m.BitsPerSymbol() > Modulation256QAM.BitsPerSymbol()
+}
+
+// DocsisMode is a DOCSIS version used by the modem, e.g. "DOCSIS 3.0".
+type DocsisMode string
+
+// List of known DOCSIS modes.
+const (
+	Docsis10 DocsisMode = "DOCSIS 1.0"
+	Docsis11 DocsisMode = "DOCSIS 1.1"
+	Docsis20 DocsisMode = "DOCSIS 2.0"
+	Docsis30 DocsisMode = "DOCSIS 3.0"
+	Docsis31 DocsisMode = "DOCSIS 3.1"
+)
+
+var docsisModes = []DocsisMode{Docsis10, Docsis11, Docsis20, Docsis30, Docsis31}
+
+// ParseDocsisMode parses DOCSIS mode in different notations, like
+// "DOCSIS 3.0", "docsis3.0" or "3.0".
+func ParseDocsisMode(s string) (DocsisMode, error) {
+	norm := strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	norm = strings.TrimPrefix(norm, "docsis")
+	for _, m := range docsisModes {
+		if strings.TrimPrefix(string(m), "DOCSIS ") == norm {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown docsis mode: %q", s)
+}
+
+// UnmarshalText normalizes known DOCSIS modes. Unknown values are kept
+// as is.
+func (m *DocsisMode) UnmarshalText(text []byte) error {
+	parsed, err := ParseDocsisMode(string(text))
+	if err != nil {
+		*m = DocsisMode(text)
+		return nil
+	}
+	*m = parsed
+	return nil
+}
+
+// String returns DOCSIS mode name.
+func (m DocsisMode) String() string {
+	return string(m)
+}
+
+// SupportsOFDM returns true if the mode supports OFDM channels.
+func (m DocsisMode) SupportsOFDM() bool {
+	return m == Docsis31
+}
+
+// ProvisionMode is an IP provisioning mode, e.g. "IPv4/IPv6".
+type ProvisionMode string
+
+// List of known provisioning modes.
+const (
+	ProvisionIPv4      ProvisionMode = "IPv4"
+	ProvisionIPv6      ProvisionMode = "IPv6"
+	ProvisionDualStack ProvisionMode = "IPv4/IPv6"
+	ProvisionDSLite    ProvisionMode = "DS-Lite"
+)
+
+var provisionModes = []ProvisionMode{
+	ProvisionIPv4,
+	ProvisionIPv6,
+	ProvisionDualStack,
+	ProvisionDSLite,
+}
+
+// ParseProvisionMode parses provisioning mode, the case is ignored.
+func ParseProvisionMode(s string) (ProvisionMode, error) {
+	for _, m := range provisionModes {
+		if strings.EqualFold(string(m), strings.TrimSpace(s)) {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown provision mode: %q", s)
+}
+
+// UnmarshalText normalizes known provisioning modes. Unknown values are
+// kept as is.
+func (m *ProvisionMode) UnmarshalText(text []byte) error {
+	parsed, err := ParseProvisionMode(string(text))
+	if err != nil {
+		*m = ProvisionMode(text)
+		return nil
+	}
+	*m = parsed
+	return nil
+}
+
+// String returns provisioning mode name.
+func (m ProvisionMode) String() string {
+	return string(m)
+}
+
+// HasIPv4 returns true if IPv4 addresses are provisioned natively.
+func (m ProvisionMode) HasIPv4() bool {
+	return m == ProvisionIPv4 || m == ProvisionDualStack
+}
+
+// HasIPv6 returns true if IPv6 addresses are provisioned.
+func (m ProvisionMode) HasIPv6() bool {
+	return m == ProvisionIPv6 || m == ProvisionDualStack || m == ProvisionDSLite
+}
+
+// FlowDirection is a direction of a service flow.
+type FlowDirection int
+
+// List of service flow directions.
+const (
+	FlowDownstream FlowDirection = 1
+	FlowUpstream   FlowDirection = 2
+)
+
+// String returns direction name.
+func (d FlowDirection) String() string {
+	switch d {
+	case FlowDownstream:
+		return "downstream"
+	case FlowUpstream:
+		return "upstream"
+	}
+	return "unknown"
+}
+
+// SchedulingType is a service flow scheduling type (DOCSIS QoS parameter).
+type SchedulingType int
+
+// List of service flow scheduling types.
+const (
+	SchedulingUndefined              SchedulingType = 1
+	SchedulingBestEffort             SchedulingType = 2
+	SchedulingNonRealTimePolling     SchedulingType = 3
+	SchedulingRealTimePolling        SchedulingType = 4
+	SchedulingUnsolicitedGrantWithAD SchedulingType = 5
+	SchedulingUnsolicitedGrant       SchedulingType = 6
+)
+
+// String returns scheduling type name.
+func (t SchedulingType) String() string {
+	switch t {
+	case SchedulingUndefined:
+		return "undefined"
+	case SchedulingBestEffort:
+		return "best effort"
+	case SchedulingNonRealTimePolling:
+		return "non-real-time polling"
+	case SchedulingRealTimePolling:
+		return "real-time polling"
+	case SchedulingUnsolicitedGrantWithAD:
+		return "unsolicited grant with activity detection"
+	case SchedulingUnsolicitedGrant:
+		return "unsolicited grant"
+	}
+	return "unknown"
+}
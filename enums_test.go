@@ -0,0 +1,265 @@
+package connectbox
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModulation(t *testing.T) {
+	testCases := []struct {
+		name string
+		str  string
+		mod  Modulation
+		err  string
+	}{
+		{
+			name: "router notation",
+			str:  "256qam",
+			mod:  Modulation256QAM,
+		},
+		{
+			name: "upper case",
+			str:  "64QAM",
+			mod:  Modulation64QAM,
+		},
+		{
+			name: "qam prefix",
+			str:  "QAM_4096",
+			mod:  Modulation4096QAM,
+		},
+		{
+			name: "ofdm",
+			str:  "OFDM",
+			mod:  ModulationOFDM,
+		},
+		{
+			name: "unknown",
+			str:  "hello",
+			err:  `unknown modulation: "hello"`,
+		},
+		{
+			name: "empty",
+			str:  "",
+			err:  `unknown modulation: ""`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mod, err := ParseModulation(tc.str)
+			if tc.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.mod, mod)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestModulation(t *testing.T) {
+	testCases := []struct {
+		mod   Modulation
+		bits  int
+		ofdm  bool
+		known bool
+	}{
+		{mod: ModulationQPSK, bits: 2, known: true},
+		{mod: Modulation64QAM, bits: 6, known: true},
+		{mod: Modulation256QAM, bits: 8, known: true},
+		{mod: Modulation4096QAM, bits: 12, ofdm: true, known: true},
+		{mod: ModulationOFDMA, bits: 0, ofdm: true, known: true},
+		{mod: Modulation("hello"), bits: 0},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.mod.String(), func(t *testing.T) {
+			require.Equal(t, tc.bits, tc.mod.BitsPerSymbol())
+			require.Equal(t, tc.ofdm, tc.mod.IsOFDM())
+			require.Equal(t, tc.known, tc.mod.Known())
+		})
+	}
+}
+
+func TestParseDocsisMode(t *testing.T) {
+	testCases := []struct {
+		name string
+		str  string
+		mode DocsisMode
+		err  string
+	}{
+		{
+			name: "router notation",
+			str:  "DOCSIS 3.0",
+			mode: Docsis30,
+		},
+		{
+			name: "short notation",
+			str:  "docsis3.1",
+			mode: Docsis31,
+		},
+		{
+			name: "only version",
+			str:  "2.0",
+			mode: Docsis20,
+		},
+		{
+			name: "unknown",
+			str:  "DOCSIS 4.0",
+			err:  `unknown docsis mode: "DOCSIS 4.0"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mode, err := ParseDocsisMode(tc.str)
+			if tc.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.mode, mode)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+
+	require.True(t, Docsis31.SupportsOFDM())
+	require.False(t, Docsis30.SupportsOFDM())
+}
+
+func TestParseProvisionMode(t *testing.T) {
+	testCases := []struct {
+		name string
+		str  string
+		mode ProvisionMode
+		ipv4 bool
+		ipv6 bool
+		err  string
+	}{
+		{
+			name: "ipv4",
+			str:  "IPv4",
+			mode: ProvisionIPv4,
+			ipv4: true,
+		},
+		{
+			name: "dual stack",
+			str:  "ipv4/ipv6",
+			mode: ProvisionDualStack,
+			ipv4: true,
+			ipv6: true,
+		},
+		{
+			name: "ds-lite",
+			str:  "DS-Lite",
+			mode: ProvisionDSLite,
+			ipv6: true,
+		},
+		{
+			name: "unknown",
+			str:  "IPX",
+			err:  `unknown provision mode: "IPX"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mode, err := ParseProvisionMode(tc.str)
+			if tc.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.mode, mode)
+				require.Equal(t, tc.ipv4, mode.HasIPv4())
+				require.Equal(t, tc.ipv6, mode.HasIPv6())
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestEnums_UnmarshalXML(t *testing.T) {
+	data := `<root>
+		<mod>QAM256</mod>
+		<unknown_mod>8psk</unknown_mod>
+		<docsis>docsis 3.1</docsis>
+		<provision>ipv6</provision>
+	</root>`
+
+	var out struct {
+		Mod        Modulation    `xml:"mod"`
+		UnknownMod Modulation    `xml:"unknown_mod"`
+		Docsis     DocsisMode    `xml:"docsis"`
+		Provision  ProvisionMode `xml:"provision"`
+	}
+	err := xml.Unmarshal([]byte(data), &out)
+	require.NoError(t, err)
+	require.Equal(t, Modulation256QAM, out.Mod)
+	require.Equal(t, Modulation("8psk"), out.UnknownMod)
+	require.Equal(t, Docsis31, out.Docsis)
+	require.Equal(t, ProvisionIPv6, out.Provision)
+}
+
+func TestFlowDirection_String(t *testing.T) {
+	require.Equal(t, "downstream", FlowDownstream.String())
+	require.Equal(t, "upstream", FlowUpstream.String())
+	require.Equal(t, "unknown", FlowDirection(0).String())
+}
+
+func TestSchedulingType_String(t *testing.T) {
+	require.Equal(t, "best effort", SchedulingBestEffort.String())
+	require.Equal(t, "unsolicited grant", SchedulingUnsolicitedGrant.String())
+	require.Equal(t, "unknown", SchedulingType(0).String())
+}
+
+func FuzzParseModulation(f *testing.F) {
+	for _, s := range []string{"256qam", "QAM256", "qam_64", "OFDM", "qpsk"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		m, err := ParseModulation(s)
+		if err != nil {
+			return
+		}
+		require.True(t, m.Known())
+		again, err := ParseModulation(m.String())
+		require.NoError(t, err)
+		require.Equal(t, m, again)
+	})
+}
+
+func FuzzParseDocsisMode(f *testing.F) {
+	for _, s := range []string{"DOCSIS 3.0", "docsis3.1", "1.1"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		m, err := ParseDocsisMode(s)
+		if err != nil {
+			return
+		}
+		require.Contains(t, docsisModes, m)
+		again, err := ParseDocsisMode(m.String())
+		require.NoError(t, err)
+		require.Equal(t, m, again)
+	})
+}
+
+func FuzzParseProvisionMode(f *testing.F) {
+	for _, s := range []string{"IPv4", "ipv4/ipv6", " DS-Lite "} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		m, err := ParseProvisionMode(s)
+		if err != nil {
+			return
+		}
+		require.Contains(t, provisionModes, m)
+		again, err := ParseProvisionMode(m.String())
+		require.NoError(t, err)
+		require.Equal(t, m, again)
+	})
+}
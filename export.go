@@ -0,0 +1,252 @@
+package connectbox
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetricsSnapshot is a set of modem metrics read at one moment.
+type MetricsSnapshot struct {
+	Time       time.Time
+	Downstream *DownstreamTable
+	Upstream   *UpstreamTable
+	Signal     *SignalTable
+	State      *CMState
+	LANUsers   *LANUserTable
+}
+
+// Metrics reads channel tables, modem state and LAN clients.
+func (z *Client) Metrics(ctx context.Context) (*MetricsSnapshot, error) {
+	m := &MetricsSnapshot{
+		Time:       time.Now(),
+		Downstream: &DownstreamTable{},
+		Upstream:   &UpstreamTable{},
+		Signal:     &SignalTable{},
+		State:      &CMState{},
+		LANUsers:   &LANUserTable{},
+	}
+	if err := z.Get(ctx, FnDownstreamTable, m.Downstream); err != nil {
+		return nil, fmt.Errorf("get downstream table: %w", err)
+	}
+	if err := z.Get(ctx, FnUpstreamTable, m.Upstream); err != nil {
+		return nil, fmt.Errorf("get upstream table: %w", err)
+	}
+	if err := z.Get(ctx, FnSignalTable, m.Signal); err != nil {
+		return nil, fmt.Errorf("get signal table: %w", err)
+	}
+	if err := z.Get(ctx, FnCMState, m.State); err != nil {
+		return nil, fmt.Errorf("get cm state: %w", err)
+	}
+	if err := z.Get(ctx, FnLANUserTable, m.LANUsers); err != nil {
+		return nil, fmt.Errorf("get lan user table: %w", err)
+	}
+	return m, nil
+}
+
+// Point is a single measurement with tags and fields, the model is
+// the same as in InfluxDB. Field values are float64, int, string or bool.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]any
+	Time        time.Time
+}
+
+// List of tag values for channel directions and client bands.
+const (
+	directionDownstream = "downstream"
+	directionUpstream   = "upstream"
+	bandEthernet        = "ethernet"
+	bandWIFI            = "wifi"
+)
+
+// Points converts the snapshot to the list of points. Empty parts of
+// the snapshot are skipped, as well as values that can't be parsed.
+func (m *MetricsSnapshot) Points() []Point {
+	var points []Point
+	add := func(measurement string, tags map[string]string, fields map[string]any) {
+		if len(fields) == 0 {
+			return
+		}
+		points = append(points, Point{
+			Measurement: measurement,
+			Tags:        tags,
+			Fields:      fields,
+			Time:        m.Time,
+		})
+	}
+
+	if m.Downstream != nil {
+		for _, d := range m.Downstream.Downstreams {
+			fields := map[string]any{}
+			addInt(fields, "frequency", d.Freq)
+			addFloat(fields, "power", d.Pow)
+			addFloat(fields, "snr", d.Snr)
+			addFloat(fields, "rx_mer", d.RxMER)
+			addString(fields, "modulation", d.Mod.String())
+			add("channel", channelTags(directionDownstream, d.Chid), fields)
+		}
+	}
+	if m.Upstream != nil {
+		for _, u := range m.Upstream.Upstreams {
+			fields := map[string]any{}
+			addInt(fields, "frequency", u.Freq)
+			addFloat(fields, "power", u.Power)
+			addFloat(fields, "symbol_rate", u.Srate)
+			addString(fields, "modulation", u.Mod.String())
+			addInt(fields, "t3_timeouts", u.T3Timeouts)
+			addInt(fields, "t4_timeouts", u.T4Timeouts)
+			add("channel", channelTags(directionUpstream, u.Usid), fields)
+		}
+	}
+	if m.Signal != nil {
+		for _, s := range m.Signal.Signals {
+			fields := map[string]any{}
+			addInt(fields, "unerrored", s.Unerrored)
+			addInt(fields, "correctable", s.Correctable)
+			addInt(fields, "uncorrectable", s.Uncorrectable)
+			add("codewords", channelTags(directionDownstream, s.Dsid), fields)
+		}
+	}
+	if m.State != nil {
+		add("modem", map[string]string{}, map[string]any{
+			"temperature":       m.State.Temperature,
+			"tuner_temperature": m.State.TunnerTemperature,
+			"operational":       m.State.OperState == OperStateOK,
+		})
+	}
+	if m.LANUsers != nil {
+		add("clients", map[string]string{"band": bandEthernet}, map[string]any{
+			"count": len(m.LANUsers.Ethernet),
+		})
+		add("clients", map[string]string{"band": bandWIFI}, map[string]any{
+			"count": len(m.LANUsers.WIFI),
+		})
+	}
+
+	return points
+}
+
+// channelTags returns tags of a channel point. Channel ID is left out if
+// it's unknown, because InfluxDB rejects empty tag values.
+func channelTags(direction, id string) map[string]string {
+	tags := map[string]string{"direction": direction}
+	if id != "" {
+		tags["channel_id"] = id
+	}
+	return tags
+}
+
+func addInt(fields map[string]any, name, value string) {
+	if v, err := strconv.Atoi(value); err == nil {
+		fields[name] = v
+	}
+}
+
+func addFloat(fields map[string]any, name, value string) {
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		fields[name] = v
+	}
+}
+
+func addString(fields map[string]any, name, value string) {
+	if value != "" {
+		fields[name] = value
+	}
+}
+
+// WriteInflux writes points in InfluxDB line protocol. Tags and fields
+// are sorted by name, timestamps are in nanoseconds.
+func WriteInflux(w io.Writer, points []Point) error {
+	for _, p := range points {
+		var b strings.Builder
+		b.WriteString(influxEscape(p.Measurement, ", "))
+		for _, k := range sortedKeys(p.Tags) {
+			b.WriteString("," + influxEscape(k, ",= ") + "=" + influxEscape(p.Tags[k], ",= "))
+		}
+		for i, k := range sortedKeys(p.Fields) {
+			if i == 0 {
+				b.WriteString(" ")
+			} else {
+				b.WriteString(",")
+			}
+			b.WriteString(influxEscape(k, ",= ") + "=" + influxField(p.Fields[k]))
+		}
+		b.WriteString(" " + strconv.FormatInt(p.Time.UnixNano(), 10) + "\n")
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+	}
+	return nil
+}
+
+func influxEscape(s, chars string) string {
+	for _, c := range chars {
+		s = strings.ReplaceAll(s, string(c), `\`+string(c))
+	}
+	return s
+}
+
+func influxField(v any) string {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v) + "i"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+	}
+	return `"` + fmt.Sprint(v) + `"`
+}
+
+// CSVHeader is a list of columns written by WriteCSV.
+var CSVHeader = []string{
+	"time", "measurement", "direction", "channel_id", "band", "field", "value",
+}
+
+// WriteCSV writes points as CSV with one row per field. Columns are
+// listed in CSVHeader, missing tags are left empty.
+func WriteCSV(w io.Writer, points []Point) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(CSVHeader); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for _, p := range points {
+		for _, k := range sortedKeys(p.Fields) {
+			row := []string{
+				p.Time.UTC().Format(time.RFC3339),
+				p.Measurement,
+				p.Tags["direction"],
+				p.Tags["channel_id"],
+				p.Tags["band"],
+				k,
+				fmt.Sprint(p.Fields[k]),
+			}
+			if err := cw.Write(row); err != nil {
+				return fmt.Errorf("write row: %w", err)
+			}
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+	return nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+package connectbox
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Metrics(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+		mockMetrics()
+
+		m, err := client.Metrics(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Len(t, m.Downstream.Downstreams, 1)
+		require.Len(t, m.Upstream.Upstreams, 1)
+		require.Len(t, m.Signal.Signals, 1)
+		require.Equal(t, 40, m.State.Temperature)
+		require.Len(t, m.LANUsers.WIFI, 2)
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=10$").
+			Reply(http.StatusInternalServerError)
+
+		_, err = client.Metrics(context.Background())
+		require.ErrorContains(t, err, "get downstream table")
+	})
+}
+
+func TestMetricsSnapshot_Points(t *testing.T) {
+	now := time.Unix(1695813641, 0)
+	m := &MetricsSnapshot{
+		Time: now,
+		Downstream: &DownstreamTable{Downstreams: []DownstreamTableDownstream{{
+			Chid:  "1",
+			Freq:  "826000000",
+			Pow:   "6",
+			Snr:   "38",
+			RxMER: "n/a",
+			Mod:   Modulation256QAM,
+		}}},
+		Upstream: &UpstreamTable{Upstreams: []UpstreamTableUpstream{{
+			Usid:       "2",
+			Freq:       "13800000",
+			Power:      "41",
+			Srate:      "5.120",
+			Mod:        Modulation64QAM,
+			T3Timeouts: "1",
+			T4Timeouts: "0",
+		}}},
+		State: &CMState{
+			Temperature:       40,
+			TunnerTemperature: 45,
+			OperState:         OperStateOK,
+		},
+	}
+
+	require.Equal(t, []Point{
+		{
+			Measurement: "channel",
+			Tags:        map[string]string{"direction": "downstream", "channel_id": "1"},
+			Fields: map[string]any{
+				"frequency":  826000000,
+				"power":      6.0,
+				"snr":        38.0,
+				"modulation": "256qam",
+			},
+			Time: now,
+		},
+		{
+			Measurement: "channel",
+			Tags:        map[string]string{"direction": "upstream", "channel_id": "2"},
+			Fields: map[string]any{
+				"frequency":   13800000,
+				"power":       41.0,
+				"symbol_rate": 5.12,
+				"modulation":  "64qam",
+				"t3_timeouts": 1,
+				"t4_timeouts": 0,
+			},
+			Time: now,
+		},
+		{
+			Measurement: "modem",
+			Tags:        map[string]string{},
+			Fields: map[string]any{
+				"temperature":       40,
+				"tuner_temperature": 45,
+				"operational":       true,
+			},
+			Time: now,
+		},
+	}, m.Points())
+}
+
+func TestChannelTags(t *testing.T) {
+	t.Run("with id", func(t *testing.T) {
+		require.Equal(t,
+			map[string]string{"direction": "upstream", "channel_id": "2"},
+			channelTags("upstream", "2"))
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		require.Equal(t,
+			map[string]string{"direction": "downstream"},
+			channelTags("downstream", ""))
+	})
+}
+
+func TestWriteInflux(t *testing.T) {
+	now := time.Unix(1695813641, 0)
+	points := []Point{
+		{
+			Measurement: "channel",
+			Tags:        map[string]string{"direction": "downstream", "channel_id": "1"},
+			Fields:      map[string]any{"power": 6.5, "modulation": "256qam"},
+			Time:        now,
+		},
+		{
+			Measurement: "modem",
+			Tags:        map[string]string{"name": "living room"},
+			Fields:      map[string]any{"temperature": 40, "operational": true},
+			Time:        now,
+		},
+		{
+			Measurement: "clients",
+			Fields:      map[string]any{"note": `say "hi"`},
+			Time:        now,
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteInflux(&buf, points))
+	require.Equal(t,
+		`channel,channel_id=1,direction=downstream modulation="256qam",power=6.5 1695813641000000000`+"\n"+
+			`modem,name=living\ room operational=true,temperature=40i 1695813641000000000`+"\n"+
+			`clients note="say \"hi\"" 1695813641000000000`+"\n",
+		buf.String())
+}
+
+func TestWriteCSV(t *testing.T) {
+	now := time.Unix(1695813641, 0)
+	points := []Point{
+		{
+			Measurement: "channel",
+			Tags:        map[string]string{"direction": "downstream", "channel_id": "1"},
+			Fields:      map[string]any{"power": 6.5, "modulation": "256qam"},
+			Time:        now,
+		},
+		{
+			Measurement: "clients",
+			Tags:        map[string]string{"band": "wifi"},
+			Fields:      map[string]any{"count": 2},
+			Time:        now,
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, points))
+	require.Equal(t,
+		"time,measurement,direction,channel_id,band,field,value\n"+
+			"2023-09-27T11:20:41Z,channel,downstream,1,,modulation,256qam\n"+
+			"2023-09-27T11:20:41Z,channel,downstream,1,,power,6.5\n"+
+			"2023-09-27T11:20:41Z,clients,,,wifi,count,2\n",
+		buf.String())
+}
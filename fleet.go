@@ -0,0 +1,231 @@
+package connectbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultFleetConcurrency is a maximum number of devices polled at once.
+const DefaultFleetConcurrency = 4
+
+// Device is a router in the fleet.
+type Device struct {
+	Name string
+	// Model is a driver name from FleetConfig.Drivers, ConnectBox client
+	// is used if it's empty
+	Model    string
+	Addr     string
+	Username string
+	Password string
+}
+
+// ModemFactory creates a client for the device, e.g. sagemcom.NewModem.
+type ModemFactory func(d Device) (Modem, error)
+
+// FleetConfig is a fleet configuration.
+type FleetConfig struct {
+	Devices []Device
+	// Concurrency is a maximum number of devices polled at once,
+	// DefaultFleetConcurrency if not set
+	Concurrency int
+	// Timeout limits a single operation on a device, including login,
+	// so one unreachable device doesn't hold the whole poll. No limit
+	// if not set.
+	Timeout time.Duration
+	// Drivers are factories of clients for other modem models, keyed
+	// by the device model
+	Drivers map[string]ModemFactory
+	// Options are applied to ConnectBox clients
+	Options []Option
+}
+
+// Fleet manages clients for many routers, that may be of different models.
+// Clients log in lazily on the first operation, and again after any
+// failure, because the session may have been lost.
+type Fleet struct {
+	cfg     FleetConfig
+	devices []*fleetDevice
+}
+
+// fleetDevice is a client of one device with its session state.
+type fleetDevice struct {
+	name  string
+	modem Modem
+
+	// mu serializes operations on the device
+	mu      sync.Mutex
+	session *Session
+}
+
+// FleetResult is a result of an operation on one device.
+type FleetResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// NewFleet creates clients for all devices. Device names must be unique,
+// and device models must have drivers.
+func NewFleet(cfg FleetConfig) (*Fleet, error) {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultFleetConcurrency
+	}
+
+	f := &Fleet{cfg: cfg}
+	names := map[string]bool{}
+	for i, d := range cfg.Devices {
+		if d.Name == "" {
+			return nil, fmt.Errorf("device %d: missing name", i)
+		}
+		if names[d.Name] {
+			return nil, fmt.Errorf("duplicate device name: %s", d.Name)
+		}
+		names[d.Name] = true
+
+		modem, err := f.newModem(d)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: %w", d.Name, err)
+		}
+		f.devices = append(f.devices, &fleetDevice{
+			name:    d.Name,
+			modem:   modem,
+			session: NewSession(modem.Login),
+		})
+	}
+	return f, nil
+}
+
+// newModem creates a client for the device using its model driver.
+func (f *Fleet) newModem(d Device) (Modem, error) {
+	if d.Model == "" {
+		return NewClient(d.Addr, d.Username, d.Password, f.cfg.Options...)
+	}
+	factory, ok := f.cfg.Drivers[d.Model]
+	if !ok {
+		return nil, fmt.Errorf("unknown model: %s", d.Model)
+	}
+	return factory(d)
+}
+
+// Names returns names of all devices in the config order.
+func (f *Fleet) Names() []string {
+	names := make([]string, len(f.devices))
+	for i, d := range f.devices {
+		names[i] = d.name
+	}
+	return names
+}
+
+// Do runs fn for all devices concurrently, and returns errors keyed by
+// device name. Devices without errors have nil values.
+func (f *Fleet) Do(ctx context.Context, fn func(ctx context.Context, m Modem) error) map[string]error {
+	results := Poll(ctx, f, func(ctx context.Context, m Modem) (struct{}, error) {
+		return struct{}{}, fn(ctx, m)
+	})
+	errs := make(map[string]error, len(results))
+	for name, res := range results {
+		errs[name] = res.Err
+	}
+	return errs
+}
+
+// Close logs out from all devices, that have an open session.
+func (f *Fleet) Close() error {
+	var errs []error
+	for _, d := range f.devices {
+		d.mu.Lock()
+		if err := d.close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d.name, err))
+		}
+		d.session.Reset()
+		d.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// close closes the device client, or logs out if the client can't be
+// closed. Must be called with d.mu held.
+func (d *fleetDevice) close() error {
+	if c, ok := d.modem.(io.Closer); ok {
+		return c.Close() //nolint:wrapcheck
+	}
+	if !d.session.LoggedIn() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	if err := d.modem.Logout(ctx); err != nil {
+		return fmt.Errorf("logout: %w", err)
+	}
+	return nil
+}
+
+// Poll runs fn for all devices of the fleet concurrently, and returns
+// results keyed by device name. A failure of one device doesn't affect
+// others. Model specific operations are available with a type assertion,
+// e.g. m.(*Client). It's a function, because methods can't have type
+// parameters.
+func Poll[T any](
+	ctx context.Context,
+	f *Fleet,
+	fn func(ctx context.Context, m Modem) (T, error),
+) map[string]FleetResult[T] {
+	results := make(map[string]FleetResult[T], len(f.devices))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, f.cfg.Concurrency)
+	for _, d := range f.devices {
+		wg.Add(1)
+		go func(d *fleetDevice) {
+			defer wg.Done()
+
+			var res FleetResult[T]
+			select {
+			case sem <- struct{}{}:
+				res.Value, res.Err = pollDevice(ctx, d, f.cfg.Timeout, fn)
+				<-sem
+			case <-ctx.Done():
+				res.Err = ctx.Err()
+			}
+
+			mu.Lock()
+			results[d.name] = res
+			mu.Unlock()
+		}(d)
+	}
+	wg.Wait()
+	return results
+}
+
+// pollDevice logs in if needed, and runs fn for the device.
+func pollDevice[T any](
+	ctx context.Context,
+	d *fleetDevice,
+	timeout time.Duration,
+	fn func(ctx context.Context, m Modem) (T, error),
+) (T, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Operations may change the device state, so they are not retried
+	var v T
+	err := d.session.Write(ctx, func(ctx context.Context) error {
+		var err error
+		v, err = fn(ctx, d.modem)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
@@ -0,0 +1,298 @@
+package connectbox
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFleet(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		f, err := NewFleet(FleetConfig{Devices: []Device{
+			{Name: "home", Addr: "192.168.178.1"},
+			{Name: "office", Addr: "192.168.0.1"},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"home", "office"}, f.Names())
+		require.Equal(t, DefaultFleetConcurrency, f.cfg.Concurrency)
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		_, err := NewFleet(FleetConfig{Devices: []Device{{Addr: "192.168.178.1"}}})
+		require.EqualError(t, err, "device 0: missing name")
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		_, err := NewFleet(FleetConfig{Devices: []Device{
+			{Name: "home", Addr: "192.168.178.1"},
+			{Name: "home", Addr: "192.168.0.1"},
+		}})
+		require.EqualError(t, err, "duplicate device name: home")
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := NewFleet(FleetConfig{Devices: []Device{{Name: "home", Addr: "hello, world!"}}})
+		require.ErrorContains(t, err, "device home: invalid address")
+	})
+
+	t.Run("unknown model", func(t *testing.T) {
+		_, err := NewFleet(FleetConfig{Devices: []Device{
+			{Name: "home", Model: "sagemcom", Addr: "192.168.100.1"},
+		}})
+		require.EqualError(t, err, "device home: unknown model: sagemcom")
+	})
+}
+
+func TestPoll(t *testing.T) {
+	getMTU := func(ctx context.Context, m Modem) (string, error) {
+		var data MTUSize
+		if err := m.(*Client).Get(ctx, FnMTUSize, &data); err != nil {
+			return "", err
+		}
+		return data.Size, nil
+	}
+
+	t.Run("lazy login", func(t *testing.T) {
+		r1, r2 := newFleetRouter(t), newFleetRouter(t)
+		f, err := NewFleet(FleetConfig{Devices: []Device{
+			{Name: "home", Addr: r1.srv.URL},
+			{Name: "office", Addr: r2.srv.URL},
+		}})
+		require.NoError(t, err)
+		require.Zero(t, r1.count(FnLogin))
+
+		for i := 0; i < 2; i++ {
+			results := Poll(context.Background(), f, getMTU)
+			require.Equal(t, map[string]FleetResult[string]{
+				"home":   {Value: "1500"},
+				"office": {Value: "1500"},
+			}, results)
+		}
+		require.Equal(t, 1, r1.count(FnLogin))
+		require.Equal(t, 1, r2.count(FnLogin))
+
+		require.NoError(t, f.Close())
+		require.Equal(t, 1, r1.count(FnLogout))
+		require.Equal(t, 1, r2.count(FnLogout))
+	})
+
+	t.Run("failed device", func(t *testing.T) {
+		good, bad := newFleetRouter(t), newFleetRouter(t)
+		bad.fail.Store(true)
+		f, err := NewFleet(FleetConfig{Devices: []Device{
+			{Name: "good", Addr: good.srv.URL},
+			{Name: "bad", Addr: bad.srv.URL},
+		}})
+		require.NoError(t, err)
+
+		results := Poll(context.Background(), f, getMTU)
+		require.Equal(t, FleetResult[string]{Value: "1500"}, results["good"])
+		require.EqualError(t, results["bad"].Err, "get response: invalid response status: 500")
+
+		// Failed device logs in again
+		bad.fail.Store(false)
+		results = Poll(context.Background(), f, getMTU)
+		require.Equal(t, FleetResult[string]{Value: "1500"}, results["bad"])
+		require.Equal(t, 1, good.count(FnLogin))
+		require.Equal(t, 2, bad.count(FnLogin))
+	})
+
+	t.Run("bounded concurrency", func(t *testing.T) {
+		var active, maxActive atomic.Int32
+		var devices []Device
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			r := newFleetRouter(t)
+			r.delay = 20 * time.Millisecond
+			r.active, r.maxActive = &active, &maxActive
+			devices = append(devices, Device{Name: name, Addr: r.srv.URL})
+		}
+		f, err := NewFleet(FleetConfig{Devices: devices, Concurrency: 2})
+		require.NoError(t, err)
+
+		results := Poll(context.Background(), f, getMTU)
+		require.Len(t, results, 5)
+		for name, res := range results {
+			require.NoError(t, res.Err, name)
+		}
+		require.Equal(t, int32(2), maxActive.Load())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		fast, slow := newFleetRouter(t), newFleetRouter(t)
+		slow.delay = time.Second
+		f, err := NewFleet(FleetConfig{
+			Devices: []Device{
+				{Name: "fast", Addr: fast.srv.URL},
+				{Name: "slow", Addr: slow.srv.URL},
+			},
+			Timeout: 50 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		start := time.Now()
+		results := Poll(context.Background(), f, getMTU)
+		require.Less(t, time.Since(start), time.Second)
+		require.NoError(t, results["fast"].Err)
+		require.ErrorIs(t, results["slow"].Err, context.DeadlineExceeded)
+	})
+
+	t.Run("mixed models", func(t *testing.T) {
+		r := newFleetRouter(t)
+		other := &fleetModem{}
+		f, err := NewFleet(FleetConfig{
+			Devices: []Device{
+				{Name: "home", Addr: r.srv.URL},
+				{Name: "office", Model: "other", Addr: "192.168.100.1", Password: "secret"},
+			},
+			Drivers: map[string]ModemFactory{
+				"other": func(d Device) (Modem, error) {
+					other.password = d.Password
+					return other, nil
+				},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "secret", other.password)
+
+		for i := 0; i < 2; i++ {
+			results := Poll(context.Background(), f, func(ctx context.Context, m Modem) (bool, error) {
+				_, isClient := m.(*Client)
+				return isClient, nil
+			})
+			require.Equal(t, map[string]FleetResult[bool]{
+				"home":   {Value: true},
+				"office": {Value: false},
+			}, results)
+		}
+		require.Equal(t, 1, other.logins)
+
+		require.NoError(t, f.Close())
+		require.Equal(t, 1, other.logouts)
+		require.Equal(t, 1, r.count(FnLogout))
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		r := newFleetRouter(t)
+		f, err := NewFleet(FleetConfig{Devices: []Device{{Name: "home", Addr: r.srv.URL}}})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		results := Poll(ctx, f, getMTU)
+		require.ErrorIs(t, results["home"].Err, context.Canceled)
+	})
+}
+
+func TestFleet_Do(t *testing.T) {
+	r := newFleetRouter(t)
+	f, err := NewFleet(FleetConfig{Devices: []Device{
+		{Name: "home", Addr: r.srv.URL},
+		{Name: "office", Addr: "http://127.0.0.1:1"},
+	}})
+	require.NoError(t, err)
+
+	errs := f.Do(context.Background(), func(ctx context.Context, m Modem) error {
+		return m.Reboot(ctx)
+	})
+	require.Len(t, errs, 2)
+	require.NoError(t, errs["home"])
+	require.ErrorContains(t, errs["office"], "login: get initial token")
+	require.Equal(t, 1, r.count(FnReboot))
+}
+
+// fleetRouter is a minimal fake router for fleet tests.
+type fleetRouter struct {
+	srv   *httptest.Server
+	delay time.Duration
+	fail  atomic.Bool
+	// active and maxActive count concurrent requests, they may be
+	// shared between routers
+	active    *atomic.Int32
+	maxActive *atomic.Int32
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newFleetRouter(t *testing.T) *fleetRouter {
+	t.Helper()
+	r := &fleetRouter{
+		calls:     map[string]int{},
+		active:    &atomic.Int32{},
+		maxActive: &atomic.Int32{},
+	}
+	r.srv = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+func (r *fleetRouter) serve(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		return
+	}
+	body, _ := io.ReadAll(req.Body)
+	args, _ := url.ParseQuery(string(body))
+	fn := args.Get("fun")
+
+	r.mu.Lock()
+	r.calls[fn]++
+	r.mu.Unlock()
+
+	switch fn {
+	case FnGlobalSettings:
+		_, _ = io.WriteString(w, "<GlobalSettings><LockedOut>Disable</LockedOut></GlobalSettings>")
+	case FnLogin:
+		_, _ = io.WriteString(w, "success;SID=sid1")
+	case FnMTUSize:
+		n := r.active.Add(1)
+		defer r.active.Add(-1)
+		for {
+			m := r.maxActive.Load()
+			if n <= m || r.maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		select {
+		case <-time.After(r.delay):
+		case <-req.Context().Done():
+			return
+		}
+		if r.fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, "<mtusize><size>1500</size></mtusize>")
+	}
+}
+
+func (r *fleetRouter) count(fn string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[fn]
+}
+
+// fleetModem is a fake modem of another model.
+type fleetModem struct {
+	Modem
+	password string
+	logins   int
+	logouts  int
+}
+
+func (m *fleetModem) Login(context.Context) error {
+	m.logins++
+	return nil
+}
+
+func (m *fleetModem) Logout(context.Context) error {
+	m.logouts++
+	return nil
+}
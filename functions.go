@@ -2,8 +2,10 @@ package connectbox
 
 // List of XML RPC setter function codes.
 const (
-	FnLogin  = "15"
-	FnLogout = "16"
+	FnReboot           = "8"
+	FnLogin            = "15"
+	FnLogout           = "16"
+	FnSetWirelessBasic = "301"
 )
 
 // List of XML RPC getter function codes.
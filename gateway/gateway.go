@@ -0,0 +1,265 @@
+// Package gateway provides an HTTP server, that exposes ConnectBox data as
+// JSON, so many consumers can share one router session.
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tetafro/connectbox"
+)
+
+// DefaultTTL is a default time to keep router responses in cache.
+const DefaultTTL = 10 * time.Second
+
+// Router is a part of connectbox.Client used by the server.
+type Router interface {
+	Login(ctx context.Context) error
+	Get(ctx context.Context, fn string, out any) error
+}
+
+// Config is a server configuration.
+type Config struct {
+	// TTL is how long responses are cached, DefaultTTL if not set
+	TTL time.Duration
+	// OnError is called when router data can't be read, it's optional
+	OnError func(error)
+}
+
+// Server is an HTTP handler, that serves router data from one session.
+// Requests to the router are serialized, because the session token
+// changes after each request.
+type Server struct {
+	router Router
+	cfg    Config
+	mux    *http.ServeMux
+	now    func() time.Time
+
+	// mu serializes access to the router
+	mu      sync.Mutex
+	session *connectbox.Session
+
+	// cmu guards the cache
+	cmu   sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	body    []byte
+	etag    string
+	expires time.Time
+}
+
+// NewServer creates new server.
+func NewServer(router Router, cfg Config) *Server {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	s := &Server{
+		router: router,
+		cfg:    cfg,
+		mux:    http.NewServeMux(),
+		now:    time.Now,
+		cache:  map[string]cacheEntry{},
+	}
+	s.session = connectbox.NewSession(router.Login)
+	s.handle("/api/v1/status", s.status)
+	s.handle("/api/v1/channels", s.channels)
+	s.handle("/api/v1/clients", s.clients)
+	s.handle("/api/v1/events", s.events)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// StatusResponse is a response format for /api/v1/status endpoint.
+type StatusResponse struct {
+	SystemInfo *connectbox.CMSystemInfo `json:"system_info"`
+	State      *connectbox.CMState      `json:"state"`
+	Status     *connectbox.Status       `json:"status"`
+}
+
+// ChannelsResponse is a response format for /api/v1/channels endpoint.
+type ChannelsResponse struct {
+	Downstream []connectbox.DownstreamTableDownstream `json:"downstream"`
+	Upstream   []connectbox.UpstreamTableUpstream     `json:"upstream"`
+	Signal     []connectbox.SignalTableSignal         `json:"signal"`
+}
+
+// ClientsResponse is a response format for /api/v1/clients endpoint.
+type ClientsResponse struct {
+	Ethernet []connectbox.LANUserTableEthernet `json:"ethernet"`
+	WIFI     []connectbox.LANUserTableWIFI     `json:"wifi"`
+}
+
+// EventsResponse is a response format for /api/v1/events endpoint.
+type EventsResponse struct {
+	Events []connectbox.EventLogTableEventLog `json:"events"`
+}
+
+// ErrorResponse is a response format for failed requests.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) status(ctx context.Context) (any, error) {
+	resp := StatusResponse{
+		SystemInfo: &connectbox.CMSystemInfo{},
+		State:      &connectbox.CMState{},
+		Status:     &connectbox.Status{},
+	}
+	if err := s.get(ctx, connectbox.FnCMSystemInfo, resp.SystemInfo); err != nil {
+		return nil, err
+	}
+	if err := s.get(ctx, connectbox.FnCMState, resp.State); err != nil {
+		return nil, err
+	}
+	if err := s.get(ctx, connectbox.FnStatus, resp.Status); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Server) channels(ctx context.Context) (any, error) {
+	var ds connectbox.DownstreamTable
+	if err := s.get(ctx, connectbox.FnDownstreamTable, &ds); err != nil {
+		return nil, err
+	}
+	var us connectbox.UpstreamTable
+	if err := s.get(ctx, connectbox.FnUpstreamTable, &us); err != nil {
+		return nil, err
+	}
+	var sig connectbox.SignalTable
+	if err := s.get(ctx, connectbox.FnSignalTable, &sig); err != nil {
+		return nil, err
+	}
+	return ChannelsResponse{
+		Downstream: ds.Downstreams,
+		Upstream:   us.Upstreams,
+		Signal:     sig.Signals,
+	}, nil
+}
+
+func (s *Server) clients(ctx context.Context) (any, error) {
+	var users connectbox.LANUserTable
+	if err := s.get(ctx, connectbox.FnLANUserTable, &users); err != nil {
+		return nil, err
+	}
+	return ClientsResponse{Ethernet: users.Ethernet, WIFI: users.WIFI}, nil
+}
+
+func (s *Server) events(ctx context.Context) (any, error) {
+	var events connectbox.EventLogTable
+	if err := s.get(ctx, connectbox.FnEventLogTable, &events); err != nil {
+		return nil, err
+	}
+	return EventsResponse{Events: events.EventLogs}, nil
+}
+
+// handle registers the handler, that serves cached result of `fetch`.
+func (s *Server) handle(path string, fetch func(ctx context.Context) (any, error)) {
+	s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed"})
+			return
+		}
+
+		entry, err := s.cached(r.Context(), path, fetch)
+		if err != nil {
+			if s.cfg.OnError != nil {
+				s.cfg.OnError(fmt.Errorf("%s: %w", path, err))
+			}
+			writeJSON(w, http.StatusBadGateway, ErrorResponse{Error: err.Error()})
+			return
+		}
+
+		age := entry.expires.Sub(s.now()) / time.Second
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(int(max(age, 0))))
+		w.Header().Set("ETag", entry.etag)
+		if r.Header.Get("If-None-Match") == entry.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(entry.body)
+	})
+}
+
+// cached returns the cached response for the path, or fetches a new one
+// if it's expired. Concurrent requests for the same expired path wait
+// for one fetch.
+func (s *Server) cached(
+	ctx context.Context,
+	path string,
+	fetch func(ctx context.Context) (any, error),
+) (cacheEntry, error) {
+	if entry, ok := s.lookup(path); ok {
+		return entry, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The response could be fetched while waiting for the lock
+	if entry, ok := s.lookup(path); ok {
+		return entry, nil
+	}
+
+	data, err := fetch(ctx)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return cacheEntry{}, fmt.Errorf("marshal response: %w", err)
+	}
+	entry := cacheEntry{
+		body:    body,
+		etag:    fmt.Sprintf(`"%x"`, sha256.Sum256(body)),
+		expires: s.now().Add(s.cfg.TTL),
+	}
+
+	s.cmu.Lock()
+	s.cache[path] = entry
+	s.cmu.Unlock()
+
+	return entry, nil
+}
+
+func (s *Server) lookup(path string) (cacheEntry, bool) {
+	s.cmu.Lock()
+	defer s.cmu.Unlock()
+	entry, ok := s.cache[path]
+	if !ok || !s.now().Before(entry.expires) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// get reads data from the router in the shared session. Must be called
+// with s.mu held.
+func (s *Server) get(ctx context.Context, fn string, out any) error {
+	return s.session.Read(ctx, func(ctx context.Context) error {
+		if err := s.router.Get(ctx, fn, out); err != nil {
+			return fmt.Errorf("get %s: %w", fn, err)
+		}
+		return nil
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,251 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tetafro/connectbox"
+)
+
+func TestServer(t *testing.T) {
+	t.Run("status", func(t *testing.T) {
+		router := &fakeRouter{}
+		srv := httptest.NewServer(NewServer(router, Config{}))
+		defer srv.Close()
+
+		var resp StatusResponse
+		status := getJSON(t, srv.URL+"/api/v1/status", &resp)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 3600, resp.SystemInfo.SystemUptime)
+		require.Equal(t, connectbox.OperStateOK, resp.State.OperState)
+		require.Equal(t, "3", resp.Status.LANUserCount)
+		require.Equal(t, 1, router.loginCount())
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		srv := httptest.NewServer(NewServer(&fakeRouter{}, Config{}))
+		defer srv.Close()
+
+		var resp ChannelsResponse
+		status := getJSON(t, srv.URL+"/api/v1/channels", &resp)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, ChannelsResponse{
+			Downstream: []connectbox.DownstreamTableDownstream{{Chid: "1", Pow: "5"}},
+			Upstream:   []connectbox.UpstreamTableUpstream{{Usid: "1", Power: "44"}},
+			Signal:     []connectbox.SignalTableSignal{{Dsid: "1", Uncorrectable: "0"}},
+		}, resp)
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		srv := httptest.NewServer(NewServer(&fakeRouter{}, Config{}))
+		defer srv.Close()
+
+		var resp ClientsResponse
+		status := getJSON(t, srv.URL+"/api/v1/clients", &resp)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, ClientsResponse{
+			Ethernet: []connectbox.LANUserTableEthernet{{Hostname: "desktop"}},
+			WIFI:     []connectbox.LANUserTableWIFI{{Hostname: "phone"}},
+		}, resp)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		srv := httptest.NewServer(NewServer(&fakeRouter{}, Config{}))
+		defer srv.Close()
+
+		var resp EventsResponse
+		status := getJSON(t, srv.URL+"/api/v1/events", &resp)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, EventsResponse{
+			Events: []connectbox.EventLogTableEventLog{{Prior: "3", Text: "No Ranging Response"}},
+		}, resp)
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		router := &fakeRouter{}
+		s := NewServer(router, Config{TTL: time.Minute})
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		s.now = func() time.Time { return now }
+		srv := httptest.NewServer(s)
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/api/v1/clients")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, "max-age=60", resp.Header.Get("Cache-Control"))
+		etag := resp.Header.Get("ETag")
+		require.NotEmpty(t, etag)
+
+		now = now.Add(30 * time.Second)
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/clients", nil)
+		require.NoError(t, err)
+		req.Header.Set("If-None-Match", etag)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotModified, resp.StatusCode)
+		require.Equal(t, "max-age=30", resp.Header.Get("Cache-Control"))
+		require.Equal(t, 1, router.calls(connectbox.FnLANUserTable))
+
+		now = now.Add(time.Minute)
+		getJSON(t, srv.URL+"/api/v1/clients", &ClientsResponse{})
+		require.Equal(t, 2, router.calls(connectbox.FnLANUserTable))
+	})
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		router := &fakeRouter{}
+		srv := httptest.NewServer(NewServer(router, Config{}))
+		defer srv.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := http.Get(srv.URL + "/api/v1/events")
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, 1, router.calls(connectbox.FnEventLogTable))
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		router := &fakeRouter{failures: 1}
+		srv := httptest.NewServer(NewServer(router, Config{}))
+		defer srv.Close()
+
+		status := getJSON(t, srv.URL+"/api/v1/events", &EventsResponse{})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, 2, router.loginCount())
+	})
+
+	t.Run("router error", func(t *testing.T) {
+		router := &fakeRouter{failures: 2}
+		errs := make(chan error, 1)
+		srv := httptest.NewServer(NewServer(router, Config{
+			OnError: func(err error) { errs <- err },
+		}))
+		defer srv.Close()
+
+		var resp ErrorResponse
+		status := getJSON(t, srv.URL+"/api/v1/events", &resp)
+		require.Equal(t, http.StatusBadGateway, status)
+		require.Equal(t, ErrorResponse{Error: "get 13: timeout"}, resp)
+		require.EqualError(t, <-errs, "/api/v1/events: get 13: timeout")
+	})
+
+	t.Run("login error", func(t *testing.T) {
+		router := &fakeRouter{loginErr: errors.New("locked out")}
+		srv := httptest.NewServer(NewServer(router, Config{}))
+		defer srv.Close()
+
+		var resp ErrorResponse
+		status := getJSON(t, srv.URL+"/api/v1/status", &resp)
+		require.Equal(t, http.StatusBadGateway, status)
+		require.Equal(t, ErrorResponse{Error: "login: locked out"}, resp)
+	})
+
+	t.Run("invalid method", func(t *testing.T) {
+		srv := httptest.NewServer(NewServer(&fakeRouter{}, Config{}))
+		defer srv.Close()
+
+		resp, err := http.Post(srv.URL+"/api/v1/status", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		require.Equal(t, "GET, HEAD", resp.Header.Get("Allow"))
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		srv := httptest.NewServer(NewServer(&fakeRouter{}, Config{}))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/api/v1/unknown")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func getJSON(t *testing.T, url string, out any) int {
+	t.Helper()
+	resp, err := http.Get(url) //nolint:noctx
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	return resp.StatusCode
+}
+
+type fakeRouter struct {
+	mu       sync.Mutex
+	logins   int
+	loginErr error
+	// failures is a number of Get calls to fail
+	failures int
+	called   map[string]int
+}
+
+func (r *fakeRouter) Login(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logins++
+	return r.loginErr
+}
+
+func (r *fakeRouter) Get(_ context.Context, fn string, out any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.called == nil {
+		r.called = map[string]int{}
+	}
+	r.called[fn]++
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("timeout")
+	}
+
+	switch out := out.(type) {
+	case *connectbox.CMSystemInfo:
+		out.SystemUptime = 3600
+	case *connectbox.CMState:
+		out.OperState = connectbox.OperStateOK
+	case *connectbox.Status:
+		out.LANUserCount = "3"
+	case *connectbox.DownstreamTable:
+		out.Downstreams = []connectbox.DownstreamTableDownstream{{Chid: "1", Pow: "5"}}
+	case *connectbox.UpstreamTable:
+		out.Upstreams = []connectbox.UpstreamTableUpstream{{Usid: "1", Power: "44"}}
+	case *connectbox.SignalTable:
+		out.Signals = []connectbox.SignalTableSignal{{Dsid: "1", Uncorrectable: "0"}}
+	case *connectbox.LANUserTable:
+		out.Ethernet = []connectbox.LANUserTableEthernet{{Hostname: "desktop"}}
+		out.WIFI = []connectbox.LANUserTableWIFI{{Hostname: "phone"}}
+	case *connectbox.EventLogTable:
+		out.EventLogs = []connectbox.EventLogTableEventLog{{Prior: "3", Text: "No Ranging Response"}}
+	}
+	return nil
+}
+
+func (r *fakeRouter) calls(fn string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.called[fn]
+}
+
+func (r *fakeRouter) loginCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.logins
+}
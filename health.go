@@ -0,0 +1,241 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Verdict is a result of a line quality check.
+type Verdict int
+
+// List of verdicts, from the best to the worst.
+const (
+	VerdictGood Verdict = iota
+	VerdictWarning
+	VerdictCritical
+)
+
+// String returns a human-readable verdict name.
+func (v Verdict) String() string {
+	switch v {
+	case VerdictGood:
+		return "good"
+	case VerdictWarning:
+		return "warning"
+	case VerdictCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// Acceptable ranges of signal levels for DOCSIS 3.0/3.1 lines.
+const (
+	// Downstream power, dBmV
+	dsPowerMin     = -7.0
+	dsPowerMax     = 7.0
+	dsPowerHardMin = -10.0
+	dsPowerHardMax = 10.0
+	// Upstream power, dBmV
+	usPowerMin     = 35.0
+	usPowerMax     = 51.0
+	usPowerHardMin = 33.0
+	usPowerHardMax = 53.0
+	// Margin above minimal SNR, below which the line is considered
+	// unstable, dB
+	snrMargin = 2.0
+	// Ratios of uncorrectable codewords
+	uncorrectableWarnRatio     = 1e-6
+	uncorrectableCriticalRatio = 1e-4
+)
+
+// minSNR is a minimal downstream SNR (dB) by modulation.
+var minSNR = map[Modulation]float64{
+	Modulation16QAM:   15,
+	Modulation64QAM:   23.5,
+	Modulation256QAM:  30,
+	Modulation1024QAM: 35,
+	Modulation4096QAM: 41,
+}
+
+// HealthReport is an assessment of the line quality.
+type HealthReport struct {
+	// Verdict is the worst verdict of all checks
+	Verdict     Verdict
+	Modem       Health
+	Downstreams []ChannelHealth
+	Upstreams   []ChannelHealth
+}
+
+// Health is a verdict with the list of found problems.
+type Health struct {
+	Verdict  Verdict
+	Problems []string
+}
+
+// ChannelHealth is a health of a single channel.
+type ChannelHealth struct {
+	ChannelID string
+	Health
+}
+
+// addProblem adds the problem and makes the verdict worse if needed.
+func (h *Health) addProblem(v Verdict, format string, args ...any) {
+	h.Problems = append(h.Problems, fmt.Sprintf(format, args...))
+	if v > h.Verdict {
+		h.Verdict = v
+	}
+}
+
+// Health reads channel tables and modem status, and checks them against
+// acceptable DOCSIS ranges.
+func (z *Client) Health(ctx context.Context) (*HealthReport, error) {
+	var ds DownstreamTable
+	if err := z.Get(ctx, FnDownstreamTable, &ds); err != nil {
+		return nil, fmt.Errorf("get downstream table: %w", err)
+	}
+	var us UpstreamTable
+	if err := z.Get(ctx, FnUpstreamTable, &us); err != nil {
+		return nil, fmt.Errorf("get upstream table: %w", err)
+	}
+	var sig SignalTable
+	if err := z.Get(ctx, FnSignalTable, &sig); err != nil {
+		return nil, fmt.Errorf("get signal table: %w", err)
+	}
+	var st CMStatus
+	if err := z.Get(ctx, FnCMStatus, &st); err != nil {
+		return nil, fmt.Errorf("get cm status: %w", err)
+	}
+	return NewHealthReport(&ds, &us, &sig, &st), nil
+}
+
+// NewHealthReport checks the channel tables and modem status against
+// acceptable DOCSIS ranges.
+func NewHealthReport(
+	ds *DownstreamTable,
+	us *UpstreamTable,
+	sig *SignalTable,
+	st *CMStatus,
+) *HealthReport {
+	r := &HealthReport{Modem: modemHealth(st)}
+	r.Verdict = r.Modem.Verdict
+
+	signals := map[string]SignalTableSignal{}
+	for _, s := range sig.Signals {
+		signals[s.Dsid] = s
+	}
+	for _, d := range ds.Downstreams {
+		h := downstreamHealth(d, signals[d.Chid])
+		r.Downstreams = append(r.Downstreams, h)
+		r.Verdict = max(r.Verdict, h.Verdict)
+	}
+	for _, u := range us.Upstreams {
+		h := upstreamHealth(u)
+		r.Upstreams = append(r.Upstreams, h)
+		r.Verdict = max(r.Verdict, h.Verdict)
+	}
+	return r
+}
+
+func modemHealth(st *CMStatus) Health {
+	var h Health
+	if st.ProvisioningSt != ProvisioningOnline {
+		h.addProblem(VerdictCritical,
+			"modem is not online (provisioning state: %s)", st.ProvisioningSt)
+	}
+	if st.CMNetworkAccess != NetworkAccessAllowed {
+		h.addProblem(VerdictCritical,
+			"network access is not allowed (%s)", st.CMNetworkAccess)
+	}
+	return h
+}
+
+func downstreamHealth(d DownstreamTableDownstream, s SignalTableSignal) ChannelHealth {
+	h := ChannelHealth{ChannelID: d.Chid}
+
+	if d.IsQamLocked != "1" || d.IsFECLocked != "1" {
+		h.addProblem(VerdictCritical, "channel is not locked")
+	}
+
+	if pow, err := strconv.ParseFloat(d.Pow, 64); err != nil {
+		h.addProblem(VerdictWarning, "invalid power value: %q", d.Pow)
+	} else if pow < dsPowerHardMin || pow > dsPowerHardMax {
+		h.addProblem(VerdictCritical,
+			"power %.1f dBmV is far outside %.0f..%.0f dBmV", pow, dsPowerMin, dsPowerMax)
+	} else if pow < dsPowerMin || pow > dsPowerMax {
+		h.addProblem(VerdictWarning,
+			"power %.1f dBmV is outside %.0f..%.0f dBmV", pow, dsPowerMin, dsPowerMax)
+	}
+
+	required, ok := minSNR[d.Mod]
+	switch snr, err := strconv.ParseFloat(d.Snr, 64); {
+	case !ok && d.Mod.IsOFDM():
+		// OFDM subcarriers use different QAM orders depending on the
+		// profile, which is not reported, so there is no single SNR
+		// requirement for the channel
+	case !ok:
+		h.addProblem(VerdictWarning, "unknown modulation: %q", d.Mod)
+	case err != nil:
+		h.addProblem(VerdictWarning, "invalid SNR value: %q", d.Snr)
+	case snr < required:
+		h.addProblem(VerdictCritical,
+			"SNR %.1f dB is below %.1f dB required for %s", snr, required, d.Mod)
+	case snr < required+snrMargin:
+		h.addProblem(VerdictWarning,
+			"SNR %.1f dB is close to %.1f dB required for %s", snr, required, d.Mod)
+	}
+
+	if s.Dsid != "" {
+		checkCodewords(&h.Health, s)
+	}
+
+	return h
+}
+
+func checkCodewords(h *Health, s SignalTableSignal) {
+	unerrored, err1 := strconv.ParseFloat(s.Unerrored, 64)
+	correctable, err2 := strconv.ParseFloat(s.Correctable, 64)
+	uncorrectable, err3 := strconv.ParseFloat(s.Uncorrectable, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		h.addProblem(VerdictWarning, "invalid codeword counters")
+		return
+	}
+	total := unerrored + correctable + uncorrectable
+	if total == 0 || uncorrectable == 0 {
+		return
+	}
+	ratio := uncorrectable / total
+	switch {
+	case ratio >= uncorrectableCriticalRatio:
+		h.addProblem(VerdictCritical,
+			"%.0f uncorrectable codewords (%.2e of total)", uncorrectable, ratio)
+	case ratio >= uncorrectableWarnRatio:
+		h.addProblem(VerdictWarning,
+			"%.0f uncorrectable codewords (%.2e of total)", uncorrectable, ratio)
+	}
+}
+
+func upstreamHealth(u UpstreamTableUpstream) ChannelHealth {
+	h := ChannelHealth{ChannelID: u.Usid}
+
+	if pow, err := strconv.ParseFloat(u.Power, 64); err != nil {
+		h.addProblem(VerdictWarning, "invalid power value: %q", u.Power)
+	} else if pow < usPowerHardMin || pow > usPowerHardMax {
+		h.addProblem(VerdictCritical,
+			"power %.1f dBmV is far outside %.0f..%.0f dBmV", pow, usPowerMin, usPowerMax)
+	} else if pow < usPowerMin || pow > usPowerMax {
+		h.addProblem(VerdictWarning,
+			"power %.1f dBmV is outside %.0f..%.0f dBmV", pow, usPowerMin, usPowerMax)
+	}
+
+	for _, t := range []struct{ name, value string }{
+		{"T3", u.T3Timeouts},
+		{"T4", u.T4Timeouts},
+	} {
+		if n, err := strconv.Atoi(t.value); err == nil && n > 0 {
+			h.addProblem(VerdictWarning, "%d %s timeouts", n, t.name)
+		}
+	}
+
+	return h
+}
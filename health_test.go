@@ -0,0 +1,299 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Health(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=10$").
+			Reply(http.StatusOK).
+			BodyString(`<downstream_table><downstream>
+				<pow>6</pow><snr>38</snr><mod>256qam</mod><chid>1</chid>
+				<IsQamLocked>1</IsQamLocked><IsFECLocked>1</IsFECLocked>
+			</downstream></downstream_table>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=11$").
+			Reply(http.StatusOK).
+			BodyString(`<upstream_table><upstream>
+				<usid>2</usid><power>41</power>
+			</upstream></upstream_table>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=12$").
+			Reply(http.StatusOK).
+			BodyString(`<signal_table><signal>
+				<dsid>1</dsid><unerrored>1000</unerrored>
+				<correctable>10</correctable><uncorrectable>0</uncorrectable>
+			</signal></signal_table>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=144$").
+			Reply(http.StatusOK).
+			BodyString(`<cmstatus>
+				<provisioning_st>Online</provisioning_st>
+				<cm_network_access>Allowed</cm_network_access>
+			</cmstatus>`)
+
+		report, err := client.Health(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, &HealthReport{
+			Verdict:     VerdictGood,
+			Downstreams: []ChannelHealth{{ChannelID: "1"}},
+			Upstreams:   []ChannelHealth{{ChannelID: "2"}},
+		}, report)
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=10$").
+			Reply(http.StatusInternalServerError)
+
+		_, err = client.Health(context.Background())
+		require.ErrorContains(t, err, "get downstream table")
+	})
+}
+
+func TestNewHealthReport(t *testing.T) {
+	goodDownstream := DownstreamTableDownstream{
+		Chid:        "1",
+		Pow:         "3",
+		Snr:         "38",
+		Mod:         "256qam",
+		IsQamLocked: "1",
+		IsFECLocked: "1",
+	}
+	goodUpstream := UpstreamTableUpstream{
+		Usid:  "1",
+		Power: "45",
+	}
+	online := CMStatus{
+		ProvisioningSt:  "Online",
+		CMNetworkAccess: "Allowed",
+	}
+
+	testCases := []struct {
+		name   string
+		ds     DownstreamTableDownstream
+		us     UpstreamTableUpstream
+		sig    SignalTableSignal
+		st     CMStatus
+		report *HealthReport
+	}{
+		{
+			name: "good",
+			ds:   goodDownstream,
+			us:   goodUpstream,
+			st:   online,
+			report: &HealthReport{
+				Verdict:     VerdictGood,
+				Downstreams: []ChannelHealth{{ChannelID: "1"}},
+				Upstreams:   []ChannelHealth{{ChannelID: "1"}},
+			},
+		},
+		{
+			name: "modem offline",
+			ds:   goodDownstream,
+			us:   goodUpstream,
+			st: CMStatus{
+				ProvisioningSt:  "Offline",
+				CMNetworkAccess: "Denied",
+			},
+			report: &HealthReport{
+				Verdict: VerdictCritical,
+				Modem: Health{
+					Verdict: VerdictCritical,
+					Problems: []string{
+						"modem is not online (provisioning state: Offline)",
+						"network access is not allowed (Denied)",
+					},
+				},
+				Downstreams: []ChannelHealth{{ChannelID: "1"}},
+				Upstreams:   []ChannelHealth{{ChannelID: "1"}},
+			},
+		},
+		{
+			name: "bad downstream",
+			ds: DownstreamTableDownstream{
+				Chid:        "1",
+				Pow:         "8.5",
+				Snr:         "31",
+				Mod:         "256qam",
+				IsQamLocked: "1",
+				IsFECLocked: "1",
+			},
+			us: goodUpstream,
+			sig: SignalTableSignal{
+				Dsid:          "1",
+				Unerrored:     "9000",
+				Correctable:   "900",
+				Uncorrectable: "100",
+			},
+			st: online,
+			report: &HealthReport{
+				Verdict: VerdictCritical,
+				Downstreams: []ChannelHealth{{
+					ChannelID: "1",
+					Health: Health{
+						Verdict: VerdictCritical,
+						Problems: []string{
+							"power 8.5 dBmV is outside -7..7 dBmV",
+							"SNR 31.0 dB is close to 30.0 dB required for 256qam",
+							"100 uncorrectable codewords (1.00e-02 of total)",
+						},
+					},
+				}},
+				Upstreams: []ChannelHealth{{ChannelID: "1"}},
+			},
+		},
+		{
+			name: "unlocked downstream",
+			ds: DownstreamTableDownstream{
+				Chid: "1",
+				Pow:  "-12",
+				Snr:  "20",
+				Mod:  "64qam",
+			},
+			us: goodUpstream,
+			st: online,
+			report: &HealthReport{
+				Verdict: VerdictCritical,
+				Downstreams: []ChannelHealth{{
+					ChannelID: "1",
+					Health: Health{
+						Verdict: VerdictCritical,
+						Problems: []string{
+							"channel is not locked",
+							"power -12.0 dBmV is far outside -7..7 dBmV",
+							"SNR 20.0 dB is below 23.5 dB required for 64qam",
+						},
+					},
+				}},
+				Upstreams: []ChannelHealth{{ChannelID: "1"}},
+			},
+		},
+		{
+			name: "bad upstream",
+			ds:   goodDownstream,
+			us: UpstreamTableUpstream{
+				Usid:       "1",
+				Power:      "52",
+				T3Timeouts: "3",
+				T4Timeouts: "0",
+			},
+			st: online,
+			report: &HealthReport{
+				Verdict:     VerdictWarning,
+				Downstreams: []ChannelHealth{{ChannelID: "1"}},
+				Upstreams: []ChannelHealth{{
+					ChannelID: "1",
+					Health: Health{
+						Verdict: VerdictWarning,
+						Problems: []string{
+							"power 52.0 dBmV is outside 35..51 dBmV",
+							"3 T3 timeouts",
+						},
+					},
+				}},
+			},
+		},
+		{
+			name: "ofdm channel",
+			ds: DownstreamTableDownstream{
+				Chid:        "33",
+				Pow:         "2",
+				Snr:         "0",
+				RxMER:       "41",
+				Mod:         ModulationOFDM,
+				IsQamLocked: "1",
+				IsFECLocked: "1",
+			},
+			us: goodUpstream,
+			st: online,
+			report: &HealthReport{
+				Verdict:     VerdictGood,
+				Downstreams: []ChannelHealth{{ChannelID: "33"}},
+				Upstreams:   []ChannelHealth{{ChannelID: "1"}},
+			},
+		},
+		{
+			name: "invalid values",
+			ds: DownstreamTableDownstream{
+				Chid:        "1",
+				Pow:         "n/a",
+				Snr:         "n/a",
+				Mod:         "qpsk",
+				IsQamLocked: "1",
+				IsFECLocked: "1",
+			},
+			us: UpstreamTableUpstream{Usid: "1", Power: "n/a"},
+			st: online,
+			report: &HealthReport{
+				Verdict: VerdictWarning,
+				Downstreams: []ChannelHealth{{
+					ChannelID: "1",
+					Health: Health{
+						Verdict: VerdictWarning,
+						Problems: []string{
+							`invalid power value: "n/a"`,
+							`unknown modulation: "qpsk"`,
+						},
+					},
+				}},
+				Upstreams: []ChannelHealth{{
+					ChannelID: "1",
+					Health: Health{
+						Verdict:  VerdictWarning,
+						Problems: []string{`invalid power value: "n/a"`},
+					},
+				}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			report := NewHealthReport(
+				&DownstreamTable{Downstreams: []DownstreamTableDownstream{tc.ds}},
+				&UpstreamTable{Upstreams: []UpstreamTableUpstream{tc.us}},
+				&SignalTable{Signals: []SignalTableSignal{tc.sig}},
+				&tc.st,
+			)
+			require.Equal(t, tc.report, report)
+		})
+	}
+}
+
+func TestVerdict_String(t *testing.T) {
+	require.Equal(t, "good", VerdictGood.String())
+	require.Equal(t, "warning", VerdictWarning.String())
+	require.Equal(t, "critical", VerdictCritical.String())
+	require.Equal(t, "unknown", Verdict(10).String())
+}
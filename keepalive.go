@@ -0,0 +1,95 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultKeepaliveInterval is an interval of keepalive requests, that is
+// shorter than the router idle timeout.
+const DefaultKeepaliveInterval = time.Minute
+
+// keepalive is a running keepalive loop.
+type keepalive struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// keepaliveKey is a context key, that marks the context passed to
+// the keepalive error callback.
+type keepaliveKey struct{}
+
+// StartKeepalive starts a background loop, that reads the login timer
+// every interval to prevent the router from closing the idle session.
+// Failures are reported to onError, which may be nil. The loop stops
+// when the context is done, or on StopKeepalive or Logout. Starting
+// a keepalive stops the previous one.
+//
+// onError runs on the loop goroutine, so it must not call StopKeepalive
+// or Close, that wait for the loop. It may call Logout with the context
+// it gets: the context is not cancelled when the loop stops, and Logout
+// doesn't wait for the loop with it.
+func (z *Client) StartKeepalive(
+	ctx context.Context,
+	interval time.Duration,
+	onError func(ctx context.Context, err error),
+) {
+	if interval <= 0 {
+		interval = DefaultKeepaliveInterval
+	}
+
+	z.StopKeepalive()
+
+	ctx, cancel := context.WithCancel(ctx)
+	k := &keepalive{cancel: cancel, done: make(chan struct{})}
+
+	z.kmu.Lock()
+	z.keepalive = k
+	z.kmu.Unlock()
+
+	go func() {
+		defer close(k.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Cache is not used, because the request must reach the router
+			var timer LoginTimer
+			err := z.getUncached(ctx, FnLoginTimer, &timer)
+			if err != nil && ctx.Err() == nil && onError != nil {
+				cbCtx := context.WithValue(context.WithoutCancel(ctx), keepaliveKey{}, k)
+				onError(cbCtx, fmt.Errorf("keepalive: %w", err))
+			}
+		}
+	}()
+}
+
+// StopKeepalive stops the keepalive loop, and waits for it to exit.
+func (z *Client) StopKeepalive() {
+	z.stopKeepalive(context.Background())
+}
+
+// stopKeepalive stops the keepalive loop, and waits for it to exit unless
+// ctx is the one passed to its error callback. The loop is blocked by
+// the callback then, and exits right after it returns.
+func (z *Client) stopKeepalive(ctx context.Context) {
+	z.kmu.Lock()
+	k := z.keepalive
+	z.keepalive = nil
+	z.kmu.Unlock()
+
+	if k == nil {
+		return
+	}
+	k.cancel()
+	if ctx.Value(keepaliveKey{}) != k {
+		<-k.done
+	}
+}
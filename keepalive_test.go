@@ -0,0 +1,232 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Keepalive(t *testing.T) {
+	t.Run("periodic requests", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("token=token1&fun=24$").
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token2; Path=/").
+			BodyString("<logintimer><Flag>0</Flag></logintimer>")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("token=token2&fun=24$").
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token3; Path=/").
+			BodyString("<logintimer><Flag>0</Flag></logintimer>")
+
+		client.StartKeepalive(context.Background(), 5*time.Millisecond, nil)
+		defer client.StopKeepalive()
+
+		require.Eventually(t, gock.IsDone, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("concurrent requests", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=24$").
+			Persist().
+			Reply(http.StatusOK).
+			BodyString("<logintimer><Flag>0</Flag></logintimer>")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Persist().
+			Reply(http.StatusOK).
+			BodyString("<mtusize><size>1500</size></mtusize>")
+
+		client.StartKeepalive(context.Background(), time.Millisecond, nil)
+		defer client.StopKeepalive()
+
+		for i := 0; i < 20; i++ {
+			var data MTUSize
+			require.NoError(t, client.Get(context.Background(), FnMTUSize, &data))
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=24$").
+			Reply(http.StatusInternalServerError)
+
+		errs := make(chan error, 1)
+		client.StartKeepalive(context.Background(), 5*time.Millisecond, func(_ context.Context, err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+		defer client.StopKeepalive()
+
+		select {
+		case err := <-errs:
+			require.EqualError(t, err, "keepalive: get response: invalid response status: 500")
+		case <-time.After(time.Second):
+			t.Fatal("error is not reported")
+		}
+	})
+
+	t.Run("stop on logout", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=16$").
+			Reply(http.StatusOK)
+
+		var mu sync.Mutex
+		var errs []error
+		client.StartKeepalive(context.Background(), 20*time.Millisecond, func(_ context.Context, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		})
+		require.NoError(t, client.Logout(context.Background()))
+		require.Nil(t, client.keepalive)
+
+		// No keepalive requests are sent after logout
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Empty(t, errs)
+	})
+
+	t.Run("logout from error callback", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=24$").
+			Reply(http.StatusInternalServerError)
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=16$").
+			Reply(http.StatusOK)
+
+		logout := make(chan error, 1)
+		client.StartKeepalive(context.Background(), 5*time.Millisecond, func(ctx context.Context, _ error) {
+			logout <- client.Logout(ctx)
+		})
+		client.kmu.Lock()
+		k := client.keepalive
+		client.kmu.Unlock()
+
+		select {
+		case err := <-logout:
+			require.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("logout is blocked")
+		}
+		select {
+		case <-k.done:
+		case <-time.After(time.Second):
+			t.Fatal("keepalive is not stopped")
+		}
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("stop waits for error callback", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=24$").
+			Reply(http.StatusInternalServerError)
+
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		client.StartKeepalive(context.Background(), 5*time.Millisecond, func(context.Context, error) {
+			close(entered)
+			<-release
+		})
+		<-entered
+
+		stopped := make(chan struct{})
+		go func() {
+			client.StopKeepalive()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			t.Fatal("stop doesn't wait for the callback")
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(release)
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("keepalive is not stopped")
+		}
+	})
+
+	t.Run("stop on context cancel", func(t *testing.T) {
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		client.StartKeepalive(ctx, time.Hour, nil)
+		k := client.keepalive
+		cancel()
+
+		select {
+		case <-k.done:
+		case <-time.After(time.Second):
+			t.Fatal("keepalive is not stopped")
+		}
+		client.StopKeepalive()
+	})
+}
@@ -0,0 +1,96 @@
+package connectbox
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrLockedOut is returned when the router admin account is locked out
+// after too many failed login attempts.
+var ErrLockedOut = errors.New("account is locked out")
+
+// LockoutError is returned when the router admin account is locked out.
+// It matches ErrLockedOut with errors.Is.
+type LockoutError struct {
+	// Remaining is the time until the end of the lockout, 0 if unknown
+	Remaining time.Duration
+	// FailCount is the number of failed login attempts, 0 if unknown
+	FailCount int
+}
+
+// Error implements error interface.
+func (e *LockoutError) Error() string {
+	if e.Remaining > 0 {
+		return fmt.Sprintf("%s, try again in %s", ErrLockedOut, e.Remaining.Round(time.Second))
+	}
+	return ErrLockedOut.Error()
+}
+
+// Is makes the error match ErrLockedOut.
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrLockedOut
+}
+
+// loginFailure builds an error for the failed login attempt. The attempt
+// may have triggered the lockout, so its state is checked.
+func (z *Client) loginFailure(ctx context.Context, resp string) error {
+	var failCount int
+	var fail Fail
+	if err := z.getUncached(ctx, FnFail, &fail); err == nil {
+		failCount, _ = strconv.Atoi(fail.FailCount)
+	}
+	if lerr := z.lockout(ctx, z.globalSettings(ctx)); lerr != nil {
+		lerr.FailCount = failCount
+		return lerr
+	}
+	if failCount > 0 {
+		return fmt.Errorf("invalid response: %s, failed attempts: %d", resp, failCount)
+	}
+	return fmt.Errorf("invalid response: %s", resp)
+}
+
+// globalSettings reads global settings, that hold the lockout state
+// and the firmware version. The settings are readable without a session,
+// but not on all firmwares, so nil is returned on errors.
+func (z *Client) globalSettings(ctx context.Context) *GlobalSettings {
+	var settings GlobalSettings
+	if err := z.getUncached(ctx, FnGlobalSettings, &settings); err != nil {
+		return nil
+	}
+	return &settings
+}
+
+// lockout returns LockoutError if the lockout of the admin account is
+// active according to settings. Nil settings are treated as no lockout.
+func (z *Client) lockout(ctx context.Context, settings *GlobalSettings) *LockoutError {
+	if settings == nil || settings.LockedOut != LockedOutEnabled {
+		return nil
+	}
+
+	lerr := &LockoutError{}
+	// Login timer holds the remaining lockout time in seconds
+	var timer LoginTimer
+	if err := z.getUncached(ctx, FnLoginTimer, &timer); err == nil {
+		if sec, err := strconv.Atoi(timer.Flag); err == nil && sec > 0 {
+			lerr.Remaining = time.Duration(sec) * time.Second
+			z.lockedUntil = time.Now().Add(lerr.Remaining)
+		}
+	}
+	return lerr
+}
+
+// getUncached is the same as Get, but always reads data from the router.
+func (z *Client) getUncached(ctx context.Context, fn string, out any) error {
+	resp, err := z.xmlRequest(ctx, xmlGetter, fn, xmlArgs{})
+	if err != nil {
+		return fmt.Errorf("get response: %w", err)
+	}
+	if err := xml.Unmarshal([]byte(resp), out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_LoginLockout(t *testing.T) {
+	t.Run("locked out before login", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockLockedOut(LockedOutEnabled)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=24$").
+			Reply(http.StatusOK).
+			BodyString("<logintimer><Flag>60</Flag></logintimer>")
+
+		err = client.Login(context.Background())
+		require.ErrorIs(t, err, ErrLockedOut)
+		require.EqualError(t, err, "account is locked out, try again in 1m0s")
+		var lerr *LockoutError
+		require.ErrorAs(t, err, &lerr)
+		require.Equal(t, time.Minute, lerr.Remaining)
+		require.True(t, gock.IsDone())
+
+		// Login is not attempted until the lockout ends
+		err = client.Login(context.Background())
+		require.ErrorIs(t, err, ErrLockedOut)
+		require.ErrorAs(t, err, &lerr)
+		require.Greater(t, lerr.Remaining, 50*time.Second)
+	})
+
+	t.Run("locked out after failed login", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockLockedOut(LockedOutDisabled)
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15").
+			Reply(http.StatusOK).
+			BodyString("fail")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=22$").
+			Reply(http.StatusOK).
+			BodyString("<fail><FailCount>3</FailCount></fail>")
+		mockLockedOut(LockedOutEnabled)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=24$").
+			Reply(http.StatusOK).
+			BodyString("<logintimer><Flag>0</Flag></logintimer>")
+
+		err = client.Login(context.Background())
+		require.ErrorIs(t, err, ErrLockedOut)
+		require.EqualError(t, err, "account is locked out")
+		var lerr *LockoutError
+		require.ErrorAs(t, err, &lerr)
+		require.Equal(t, &LockoutError{FailCount: 3}, lerr)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("lockout state is not available", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusForbidden)
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15").
+			Reply(http.StatusOK).
+			BodyString("success;SID=sid1")
+
+		err = client.Login(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+	})
+}
+
+func TestLockoutError(t *testing.T) {
+	err := &LockoutError{Remaining: 90*time.Second + 300*time.Millisecond}
+	require.EqualError(t, err, "account is locked out, try again in 1m30s")
+	require.ErrorIs(t, err, ErrLockedOut)
+	require.EqualError(t, &LockoutError{}, "account is locked out")
+}
+
+// mockLockedOut sets up gock mock for global settings with the lockout
+// state.
+func mockLockedOut(state string) {
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=1$").
+		Reply(http.StatusOK).
+		BodyString("<GlobalSettings><LockedOut>" + state + "</LockedOut></GlobalSettings>")
+}
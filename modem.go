@@ -0,0 +1,208 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modem is a cable modem, that can be monitored and rebooted. Client
+// implements it for Compal ConnectBox, other models are implemented by
+// separate packages (e.g. sagemcom), so tools can work with all of them.
+type Modem interface {
+	Login(ctx context.Context) error
+	Logout(ctx context.Context) error
+	ModemStatus(ctx context.Context) (*ModemStatus, error)
+	Channels(ctx context.Context) (*Channels, error)
+	Events(ctx context.Context) ([]Event, error)
+	Clients(ctx context.Context) ([]LANClient, error)
+	Reboot(ctx context.Context) error
+}
+
+var _ Modem = (*Client)(nil)
+
+// ModemStatus is a model independent modem status.
+type ModemStatus struct {
+	Model           string
+	SoftwareVersion string
+	HardwareVersion string
+	SerialNumber    string
+	MACAddr         string
+	DocsisMode      DocsisMode
+	Uptime          time.Duration
+	// Online is true if the modem is operational and has network access
+	Online      bool
+	WANIPv4Addr string
+}
+
+// Channels is a list of downstream and upstream channels.
+type Channels struct {
+	Downstream []Channel
+	Upstream   []Channel
+}
+
+// Channel is a model independent channel state. Values, that are not
+// reported by the modem, are zero.
+type Channel struct {
+	ID         string
+	Frequency  int64   // Hz
+	Power      float64 // dBmV
+	SNR        float64 // dB, downstream only
+	Modulation Modulation
+	Locked     bool
+	// Codeword counters, downstream only
+	Correctable   int64
+	Uncorrectable int64
+}
+
+// Event is a record from the modem event log.
+type Event struct {
+	Time     time.Time
+	Priority string
+	Text     string
+}
+
+// LANClient is a device connected to the modem LAN.
+type LANClient struct {
+	Hostname string
+	MACAddr  string
+	IPAddr   string
+	Wireless bool
+}
+
+// ModemStatus reads the modem details and its online state.
+func (z *Client) ModemStatus(ctx context.Context) (*ModemStatus, error) {
+	var settings GlobalSettings
+	if err := z.Get(ctx, FnGlobalSettings, &settings); err != nil {
+		return nil, fmt.Errorf("get global settings: %w", err)
+	}
+	var info CMSystemInfo
+	if err := z.Get(ctx, FnCMSystemInfo, &info); err != nil {
+		return nil, fmt.Errorf("get cm system info: %w", err)
+	}
+	var state CMState
+	if err := z.Get(ctx, FnCMState, &state); err != nil {
+		return nil, fmt.Errorf("get cm state: %w", err)
+	}
+	return &ModemStatus{
+		Model:           settings.ConfigVenderModel,
+		SoftwareVersion: settings.SwVersion,
+		HardwareVersion: info.HardwareVersion,
+		SerialNumber:    info.SerialNumber,
+		MACAddr:         info.MacAddr,
+		DocsisMode:      info.DocsisMode,
+		Uptime:          time.Duration(info.SystemUptime) * time.Second,
+		Online: state.OperState == OperStateOK &&
+			info.NetworkAccess == NetworkAccessAllowed,
+		WANIPv4Addr: state.WANIPv4Addr,
+	}, nil
+}
+
+// Channels reads downstream and upstream channel tables. Unparsable
+// values are left zero.
+func (z *Client) Channels(ctx context.Context) (*Channels, error) {
+	var ds DownstreamTable
+	if err := z.Get(ctx, FnDownstreamTable, &ds); err != nil {
+		return nil, fmt.Errorf("get downstream table: %w", err)
+	}
+	var us UpstreamTable
+	if err := z.Get(ctx, FnUpstreamTable, &us); err != nil {
+		return nil, fmt.Errorf("get upstream table: %w", err)
+	}
+	var sig SignalTable
+	if err := z.Get(ctx, FnSignalTable, &sig); err != nil {
+		return nil, fmt.Errorf("get signal table: %w", err)
+	}
+
+	signals := map[string]SignalTableSignal{}
+	for _, s := range sig.Signals {
+		signals[s.Dsid] = s
+	}
+	channels := &Channels{}
+	for _, d := range ds.Downstreams {
+		s := signals[d.Chid]
+		channels.Downstream = append(channels.Downstream, Channel{
+			ID:            d.Chid,
+			Frequency:     parseInt(d.Freq),
+			Power:         parseFloat(d.Pow),
+			SNR:           parseFloat(d.Snr),
+			Modulation:    d.Mod,
+			Locked:        d.IsQamLocked == "1" && d.IsFECLocked == "1",
+			Correctable:   parseInt(s.Correctable),
+			Uncorrectable: parseInt(s.Uncorrectable),
+		})
+	}
+	for _, u := range us.Upstreams {
+		channels.Upstream = append(channels.Upstream, Channel{
+			ID:         u.Usid,
+			Frequency:  parseInt(u.Freq),
+			Power:      parseFloat(u.Power),
+			Modulation: u.Mod,
+			// Upstream table lists only active channels
+			Locked: true,
+		})
+	}
+	return channels, nil
+}
+
+// Events reads the modem event log.
+func (z *Client) Events(ctx context.Context) ([]Event, error) {
+	var log EventLogTable
+	if err := z.Get(ctx, FnEventLogTable, &log); err != nil {
+		return nil, fmt.Errorf("get event log table: %w", err)
+	}
+	events := make([]Event, 0, len(log.EventLogs))
+	for _, e := range log.EventLogs {
+		// Field t is a unix timestamp of the time field
+		var t time.Time
+		if sec := parseInt(e.T); sec > 0 {
+			t = time.Unix(sec, 0).UTC()
+		}
+		events = append(events, Event{Time: t, Priority: e.Prior, Text: e.Text})
+	}
+	return events, nil
+}
+
+// Clients reads the list of devices connected to the LAN.
+func (z *Client) Clients(ctx context.Context) ([]LANClient, error) {
+	var users LANUserTable
+	if err := z.Get(ctx, FnLANUserTable, &users); err != nil {
+		return nil, fmt.Errorf("get lan user table: %w", err)
+	}
+	var clients []LANClient
+	for _, c := range users.Ethernet {
+		clients = append(clients, LANClient{
+			Hostname: c.Hostname,
+			MACAddr:  c.MACAddr,
+			IPAddr:   stripPrefixLen(c.IPv4Addr),
+		})
+	}
+	for _, c := range users.WIFI {
+		clients = append(clients, LANClient{
+			Hostname: c.Hostname,
+			MACAddr:  c.MACAddr,
+			IPAddr:   stripPrefixLen(c.IPv4Addr),
+			Wireless: true,
+		})
+	}
+	return clients, nil
+}
+
+func parseInt(s string) int64 {
+	n, _ := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return n
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
+
+// stripPrefixLen removes the prefix length from the address, e.g.
+// 10.0.0.1/24 becomes 10.0.0.1.
+func stripPrefixLen(addr string) string {
+	ip, _, _ := strings.Cut(addr, "/")
+	return ip
+}
@@ -0,0 +1,209 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Modem(t *testing.T) {
+	defer gock.Off()
+
+	client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+	require.NoError(t, err)
+	client.token = "token1"
+
+	gock.InterceptClient(client.http)
+
+	ctx := context.Background()
+
+	t.Run("status", func(t *testing.T) {
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=1$").
+			Reply(http.StatusOK).
+			BodyString(`<GlobalSettings>
+				<SwVersion>CH7465LG-NCIP-6.15.30-1p3-1-NOSH</SwVersion>
+				<ConfigVenderModel>CH7465LG</ConfigVenderModel>
+			</GlobalSettings>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=2$").
+			Reply(http.StatusOK).
+			BodyString(`<cm_system_info>
+				<cm_docsis_mode>DOCSIS 3.0</cm_docsis_mode>
+				<cm_hardware_version>5.01</cm_hardware_version>
+				<cm_mac_addr>00:11:22:33:44:55</cm_mac_addr>
+				<cm_serial_number>DEAP1300000A</cm_serial_number>
+				<cm_system_uptime>4day(s)16h:30m:35s</cm_system_uptime>
+				<cm_network_access>Allowed</cm_network_access>
+			</cm_system_info>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=136$").
+			Reply(http.StatusOK).
+			BodyString(`<cmstate>
+				<OperState>OPERATIONAL</OperState>
+				<wan_ipv4_addr>1.2.3.4</wan_ipv4_addr>
+			</cmstate>`)
+
+		status, err := client.ModemStatus(ctx)
+		require.NoError(t, err)
+		require.Equal(t, &ModemStatus{
+			Model:           "CH7465LG",
+			SoftwareVersion: "CH7465LG-NCIP-6.15.30-1p3-1-NOSH",
+			HardwareVersion: "5.01",
+			SerialNumber:    "DEAP1300000A",
+			MACAddr:         "00:11:22:33:44:55",
+			DocsisMode:      Docsis30,
+			Uptime:          405035 * time.Second,
+			Online:          true,
+			WANIPv4Addr:     "1.2.3.4",
+		}, status)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=10$").
+			Reply(http.StatusOK).
+			BodyString(`<downstream_table>
+				<downstream>
+					<freq>826000000</freq>
+					<pow>6</pow>
+					<snr>38</snr>
+					<mod>256qam</mod>
+					<chid>32</chid>
+					<IsQamLocked>1</IsQamLocked>
+					<IsFECLocked>1</IsFECLocked>
+				</downstream>
+				<downstream>
+					<freq>754000000</freq>
+					<pow>-1.5</pow>
+					<snr>n/a</snr>
+					<mod>256qam</mod>
+					<chid>31</chid>
+					<IsQamLocked>1</IsQamLocked>
+					<IsFECLocked>0</IsFECLocked>
+				</downstream>
+			</downstream_table>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=11$").
+			Reply(http.StatusOK).
+			BodyString(`<upstream_table><upstream>
+				<usid>9</usid>
+				<freq>13800000</freq>
+				<power>41</power>
+				<mod>64qam</mod>
+			</upstream></upstream_table>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=12$").
+			Reply(http.StatusOK).
+			BodyString(`<signal_table><signal>
+				<dsid>32</dsid>
+				<unerrored>1000</unerrored>
+				<correctable>20</correctable>
+				<uncorrectable>3</uncorrectable>
+			</signal></signal_table>`)
+
+		channels, err := client.Channels(ctx)
+		require.NoError(t, err)
+		require.Equal(t, &Channels{
+			Downstream: []Channel{
+				{
+					ID:            "32",
+					Frequency:     826000000,
+					Power:         6,
+					SNR:           38,
+					Modulation:    Modulation256QAM,
+					Locked:        true,
+					Correctable:   20,
+					Uncorrectable: 3,
+				},
+				{
+					ID:         "31",
+					Frequency:  754000000,
+					Power:      -1.5,
+					Modulation: Modulation256QAM,
+				},
+			},
+			Upstream: []Channel{
+				{
+					ID:         "9",
+					Frequency:  13800000,
+					Power:      41,
+					Modulation: Modulation64QAM,
+					Locked:     true,
+				},
+			},
+		}, channels)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("events", func(t *testing.T) {
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=13$").
+			Reply(http.StatusOK).
+			BodyString(`<eventlog_table><eventlog>
+				<prior>notice</prior>
+				<text>GUI Login Status - Login Success from LAN interface</text>
+				<time>20-09-2023 14:40:41</time>
+				<t>1695813641</t>
+			</eventlog></eventlog_table>`)
+
+		events, err := client.Events(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []Event{{
+			Time:     time.Unix(1695813641, 0).UTC(),
+			Priority: "notice",
+			Text:     "GUI Login Status - Login Success from LAN interface",
+		}}, events)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=123$").
+			Reply(http.StatusOK).
+			BodyString(`<LanUserTable>
+				<Ethernet><clientinfo>
+					<IPv4Addr>10.0.0.2/24</IPv4Addr>
+					<hostname>tv</hostname>
+					<MACAddr>00:11:22:33:44:44</MACAddr>
+				</clientinfo></Ethernet>
+				<WIFI><clientinfo>
+					<IPv4Addr>10.0.0.3/24</IPv4Addr>
+					<hostname>laptop</hostname>
+					<MACAddr>00:11:22:33:44:77</MACAddr>
+				</clientinfo></WIFI>
+			</LanUserTable>`)
+
+		clients, err := client.Clients(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []LANClient{
+			{Hostname: "tv", MACAddr: "00:11:22:33:44:44", IPAddr: "10.0.0.2"},
+			{Hostname: "laptop", MACAddr: "00:11:22:33:44:77", IPAddr: "10.0.0.3", Wireless: true},
+		}, clients)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=13$").
+			Reply(http.StatusInternalServerError)
+
+		_, err := client.Events(ctx)
+		require.EqualError(t, err,
+			"get event log table: get response: invalid response status: 500")
+	})
+}
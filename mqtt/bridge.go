@@ -0,0 +1,314 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/tetafro/connectbox"
+)
+
+// Router is a part of connectbox.Client used by the bridge.
+type Router interface {
+	Login(ctx context.Context) error
+	Get(ctx context.Context, fn string, out any) error
+	GuestNetwork(ctx context.Context) (bool, error)
+	Reboot(ctx context.Context) error
+}
+
+// List of payloads used in state and command topics.
+const (
+	payloadOn      = "ON"
+	payloadOff     = "OFF"
+	payloadPress   = "PRESS"
+	payloadOnline  = "online"
+	payloadOffline = "offline"
+)
+
+// Config is a bridge configuration.
+type Config struct {
+	// DeviceID is a unique device ID used in topics and entity IDs
+	DeviceID string
+	// TopicPrefix is a prefix for state and command topics
+	TopicPrefix string
+	// DiscoveryPrefix is Home Assistant discovery prefix,
+	// "homeassistant" by default
+	DiscoveryPrefix string
+	// Interval is a period of publishing modem state
+	Interval time.Duration
+	// OnError is called when the modem state can't be read, or a command
+	// fails, it's optional
+	OnError func(error)
+}
+
+// Bridge publishes modem state to MQTT with Home Assistant discovery
+// configs, and executes commands from MQTT topics.
+type Bridge struct {
+	router Router
+	cfg    Config
+	// session is only used by Run, so it's not guarded
+	session *connectbox.Session
+}
+
+// NewBridge creates new bridge.
+func NewBridge(router Router, cfg Config) *Bridge {
+	if cfg.DiscoveryPrefix == "" {
+		cfg.DiscoveryPrefix = "homeassistant"
+	}
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = cfg.DeviceID
+	}
+	return &Bridge{
+		router:  router,
+		cfg:     cfg,
+		session: connectbox.NewSession(router.Login),
+	}
+}
+
+// State is a modem state published to the state topic.
+type State struct {
+	OperState        string `json:"oper_state"`
+	Temperature      int    `json:"temperature"`
+	TunerTemperature int    `json:"tuner_temperature"`
+	Uptime           int    `json:"uptime"`
+	WANIP            string `json:"wan_ip"`
+	WIFI2G           string `json:"wifi_2g"`
+	WIFI5G           string `json:"wifi_5g"`
+	GuestNetwork     string `json:"guest_network"`
+	Clients          int    `json:"clients"`
+}
+
+// Will returns the message that should be set as the connection will,
+// so Home Assistant marks the modem unavailable when the bridge dies.
+func (b *Bridge) Will() *Message {
+	return &Message{
+		Topic:   b.availabilityTopic(),
+		Payload: []byte(payloadOffline),
+		Retain:  true,
+	}
+}
+
+// Run publishes discovery configs, and then publishes modem state every
+// interval, and executes received commands. The bridge logs in to the
+// router itself, and logs in again when the session is lost, e.g. after
+// a reboot. It stops when the context is done or the connection is closed.
+func (b *Bridge) Run(ctx context.Context, conn *Conn) error {
+	for _, msg := range b.discovery() {
+		if err := conn.Publish(msg); err != nil {
+			return fmt.Errorf("publish discovery: %w", err)
+		}
+	}
+	if err := conn.Subscribe(ctx, b.rebootCommandTopic()); err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+	if err := b.publishAvailability(conn, payloadOnline); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+
+	if err := b.publishState(ctx, conn); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if err := b.publishAvailability(conn, payloadOffline); err != nil {
+				return err
+			}
+			return ctx.Err() //nolint:wrapcheck
+		case <-conn.Done():
+			return fmt.Errorf("connection closed: %w", conn.Err())
+		case msg, ok := <-conn.Messages():
+			if !ok {
+				continue // connection is closing
+			}
+			b.handle(ctx, msg)
+			if err := b.publishState(ctx, conn); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := b.publishState(ctx, conn); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ReadState reads the modem state. The router must be logged in.
+func (b *Bridge) ReadState(ctx context.Context) (*State, error) {
+	var state connectbox.CMState
+	if err := b.router.Get(ctx, connectbox.FnCMState, &state); err != nil {
+		return nil, fmt.Errorf("get cm state: %w", err)
+	}
+	var info connectbox.CMSystemInfo
+	if err := b.router.Get(ctx, connectbox.FnCMSystemInfo, &info); err != nil {
+		return nil, fmt.Errorf("get cm system info: %w", err)
+	}
+	var status connectbox.Status
+	if err := b.router.Get(ctx, connectbox.FnStatus, &status); err != nil {
+		return nil, fmt.Errorf("get status: %w", err)
+	}
+	guest, err := b.router.GuestNetwork(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get guest network: %w", err)
+	}
+	clients, _ := strconv.Atoi(status.LANUserCount)
+
+	return &State{
+		OperState:        state.OperState,
+		Temperature:      state.Temperature,
+		TunerTemperature: state.TunnerTemperature,
+		Uptime:           info.SystemUptime,
+		WANIP:            state.WANIPv4Addr,
+		WIFI2G:           onOff(status.BSSEnable2G == connectbox.WirelessEnabled),
+		WIFI5G:           onOff(status.BssEnable5G == connectbox.WirelessEnabled),
+		GuestNetwork:     onOff(guest),
+		Clients:          clients,
+	}, nil
+}
+
+// publishState publishes the modem state. Errors of reading the state
+// are reported to OnError, only publishing errors are returned.
+func (b *Bridge) publishState(ctx context.Context, conn *Conn) error {
+	state, err := b.readState(ctx)
+	if err != nil {
+		b.onError(err)
+		return nil
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+	msg := Message{Topic: b.stateTopic(), Payload: payload, Retain: true}
+	if err := conn.Publish(msg); err != nil {
+		return fmt.Errorf("publish state: %w", err)
+	}
+	return nil
+}
+
+// readState reads the modem state in the shared session.
+func (b *Bridge) readState(ctx context.Context) (*State, error) {
+	var state *State
+	err := b.session.Read(ctx, func(ctx context.Context) error {
+		var err error
+		state, err = b.ReadState(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (b *Bridge) publishAvailability(conn *Conn, payload string) error {
+	msg := Message{
+		Topic:   b.availabilityTopic(),
+		Payload: []byte(payload),
+		Retain:  true,
+	}
+	if err := conn.Publish(msg); err != nil {
+		return fmt.Errorf("publish availability: %w", err)
+	}
+	return nil
+}
+
+func (b *Bridge) handle(ctx context.Context, msg Message) {
+	var cmd func(ctx context.Context) error
+	switch {
+	case msg.Topic == b.rebootCommandTopic() && string(msg.Payload) == payloadPress:
+		cmd = b.router.Reboot
+	default:
+		b.onError(fmt.Errorf("handle command: unknown command: %s %q", msg.Topic, msg.Payload))
+		return
+	}
+	if err := b.session.Write(ctx, cmd); err != nil {
+		b.onError(fmt.Errorf("handle command: %w", err))
+	}
+}
+
+func (b *Bridge) onError(err error) {
+	if b.cfg.OnError != nil {
+		b.cfg.OnError(err)
+	}
+}
+
+// discovery returns Home Assistant discovery configs for all entities.
+func (b *Bridge) discovery() []Message {
+	device := map[string]any{
+		"identifiers":  []string{b.cfg.DeviceID},
+		"name":         "ConnectBox",
+		"manufacturer": "Compal",
+	}
+	entity := func(component, id, name string, extra map[string]any) Message {
+		cfg := map[string]any{
+			"name":               name,
+			"unique_id":          b.cfg.DeviceID + "_" + id,
+			"availability_topic": b.availabilityTopic(),
+			"device":             device,
+		}
+		for k, v := range extra {
+			cfg[k] = v
+		}
+		payload, _ := json.Marshal(cfg) //nolint:errchkjson
+		return Message{
+			Topic: fmt.Sprintf("%s/%s/%s/%s/config",
+				b.cfg.DiscoveryPrefix, component, b.cfg.DeviceID, id),
+			Payload: payload,
+			Retain:  true,
+		}
+	}
+	state := func(field string) map[string]any {
+		return map[string]any{
+			"state_topic":    b.stateTopic(),
+			"value_template": "{{ value_json." + field + " }}",
+		}
+	}
+	with := func(m map[string]any, kv ...any) map[string]any {
+		for i := 0; i < len(kv); i += 2 {
+			m[kv[i].(string)] = kv[i+1]
+		}
+		return m
+	}
+
+	return []Message{
+		entity("sensor", "oper_state", "Operational state", state("oper_state")),
+		entity("sensor", "temperature", "Temperature", with(state("temperature"),
+			"device_class", "temperature", "unit_of_measurement", "°C")),
+		entity("sensor", "tuner_temperature", "Tuner temperature", with(state("tuner_temperature"),
+			"device_class", "temperature", "unit_of_measurement", "°C")),
+		entity("sensor", "uptime", "Uptime", with(state("uptime"),
+			"device_class", "duration", "unit_of_measurement", "s")),
+		entity("sensor", "wan_ip", "WAN IP", state("wan_ip")),
+		entity("sensor", "clients", "Clients", state("clients")),
+		entity("binary_sensor", "wifi_2g", "Wi-Fi 2.4 GHz", state("wifi_2g")),
+		entity("binary_sensor", "wifi_5g", "Wi-Fi 5 GHz", state("wifi_5g")),
+		entity("binary_sensor", "guest_network", "Guest network", state("guest_network")),
+		entity("button", "reboot", "Reboot", map[string]any{
+			"command_topic": b.rebootCommandTopic(),
+			"device_class":  "restart",
+		}),
+	}
+}
+
+func (b *Bridge) stateTopic() string {
+	return b.cfg.TopicPrefix + "/state"
+}
+
+func (b *Bridge) availabilityTopic() string {
+	return b.cfg.TopicPrefix + "/availability"
+}
+
+func (b *Bridge) rebootCommandTopic() string {
+	return b.cfg.TopicPrefix + "/reboot"
+}
+
+func onOff(v bool) string {
+	if v {
+		return payloadOn
+	}
+	return payloadOff
+}
@@ -0,0 +1,258 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tetafro/connectbox"
+)
+
+func TestBridge(t *testing.T) {
+	t.Run("discovery and state", func(t *testing.T) {
+		b := newTestBroker(t)
+		router := &fakeRouter{}
+		bridge := NewBridge(router, Config{DeviceID: "cb1", Interval: time.Hour})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := runBridge(t, ctx, b, bridge)
+
+		b.waitRetained(t, "cb1/availability")
+		msg, _ := b.published("cb1/availability")
+		require.Equal(t, "online", string(msg.Payload))
+
+		msg, ok := b.published("homeassistant/binary_sensor/cb1/guest_network/config")
+		require.True(t, ok)
+		var cfg map[string]any
+		require.NoError(t, json.Unmarshal(msg.Payload, &cfg))
+		require.Equal(t, "cb1_guest_network", cfg["unique_id"])
+		require.Equal(t, "cb1/state", cfg["state_topic"])
+		require.NotContains(t, cfg, "command_topic")
+		require.Equal(t, "cb1/availability", cfg["availability_topic"])
+		for _, topic := range []string{
+			"homeassistant/sensor/cb1/oper_state/config",
+			"homeassistant/sensor/cb1/temperature/config",
+			"homeassistant/sensor/cb1/tuner_temperature/config",
+			"homeassistant/sensor/cb1/uptime/config",
+			"homeassistant/sensor/cb1/wan_ip/config",
+			"homeassistant/sensor/cb1/clients/config",
+			"homeassistant/binary_sensor/cb1/wifi_2g/config",
+			"homeassistant/binary_sensor/cb1/wifi_5g/config",
+			"homeassistant/button/cb1/reboot/config",
+		} {
+			_, ok := b.published(topic)
+			require.True(t, ok, topic)
+		}
+
+		state := readState(t, b, "cb1/state")
+		require.Equal(t, State{
+			OperState:        "OPERATIONAL",
+			Temperature:      40,
+			TunerTemperature: 45,
+			Uptime:           3600,
+			WANIP:            "10.0.0.10",
+			WIFI2G:           "ON",
+			WIFI5G:           "OFF",
+			GuestNetwork:     "OFF",
+			Clients:          3,
+		}, state)
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+		require.Eventually(t, func() bool {
+			msg, _ := b.published("cb1/availability")
+			return string(msg.Payload) == "offline"
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("commands", func(t *testing.T) {
+		b := newTestBroker(t)
+		router := &fakeRouter{}
+		bridge := NewBridge(router, Config{
+			DeviceID:    "cb1",
+			TopicPrefix: "home/cb",
+			Interval:    time.Hour,
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		runBridge(t, ctx, b, bridge)
+		b.waitRetained(t, "home/cb/availability")
+
+		pub, err := Dial(context.Background(), b.addr(), Options{ClientID: "ha"})
+		require.NoError(t, err)
+		defer pub.Close()
+
+		require.NoError(t, pub.Publish(Message{Topic: "home/cb/reboot", Payload: []byte("PRESS")}))
+		require.Eventually(t, func() bool {
+			router.mu.Lock()
+			defer router.mu.Unlock()
+			return router.reboots == 1
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("router errors", func(t *testing.T) {
+		b := newTestBroker(t)
+		router := &fakeRouter{err: errors.New("timeout")}
+
+		var mu sync.Mutex
+		var errs []string
+		bridge := NewBridge(router, Config{
+			DeviceID: "cb1",
+			Interval: time.Hour,
+			OnError: func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err.Error())
+			},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		runBridge(t, ctx, b, bridge)
+		b.waitRetained(t, "cb1/availability")
+
+		pub, err := Dial(context.Background(), b.addr(), Options{ClientID: "ha"})
+		require.NoError(t, err)
+		defer pub.Close()
+		require.NoError(t, pub.Publish(Message{Topic: "cb1/reboot", Payload: []byte("NOW")}))
+
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(errs) == 3
+		}, time.Second, 5*time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, []string{
+			"get cm state: timeout",
+			`handle command: unknown command: cb1/reboot "NOW"`,
+			"get cm state: timeout",
+		}, errs)
+	})
+}
+
+func TestBridge_Relogin(t *testing.T) {
+	b := newTestBroker(t)
+	router := &fakeRouter{}
+
+	var mu sync.Mutex
+	var errs []error
+	bridge := NewBridge(router, Config{
+		DeviceID: "cb1",
+		Interval: 5 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runBridge(t, ctx, b, bridge)
+	readState(t, b, "cb1/state")
+
+	// Session is closed, e.g. by reboot
+	router.mu.Lock()
+	router.expired = true
+	router.mu.Unlock()
+
+	require.Eventually(t, func() bool {
+		router.mu.Lock()
+		defer router.mu.Unlock()
+		return router.logins == 2 && !router.expired
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Empty(t, errs)
+}
+
+// runBridge connects the bridge to the broker and runs it in background.
+func runBridge(t *testing.T, ctx context.Context, b *testBroker, bridge *Bridge) <-chan error {
+	t.Helper()
+	conn, err := Dial(ctx, b.addr(), Options{ClientID: "bridge", Will: bridge.Will()})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	done := make(chan error, 1)
+	go func() { done <- bridge.Run(ctx, conn) }()
+	return done
+}
+
+func readState(t *testing.T, b *testBroker, topic string) State {
+	t.Helper()
+	var msg Message
+	require.Eventually(t, func() bool {
+		var ok bool
+		msg, ok = b.published(topic)
+		return ok
+	}, time.Second, 5*time.Millisecond)
+	var state State
+	require.NoError(t, json.Unmarshal(msg.Payload, &state))
+	return state
+}
+
+type fakeRouter struct {
+	mu      sync.Mutex
+	err     error
+	guest   bool
+	reboots int
+	logins  int
+	// expired is set when the router closes the session
+	expired bool
+}
+
+func (r *fakeRouter) Login(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logins++
+	r.expired = false
+	return nil
+}
+
+func (r *fakeRouter) Get(_ context.Context, fn string, out any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.expired {
+		return errors.New("session expired")
+	}
+	if r.err != nil {
+		return r.err
+	}
+	switch out := out.(type) {
+	case *connectbox.CMState:
+		out.OperState = connectbox.OperStateOK
+		out.Temperature = 40
+		out.TunnerTemperature = 45
+		out.WANIPv4Addr = "10.0.0.10"
+	case *connectbox.CMSystemInfo:
+		out.SystemUptime = 3600
+	case *connectbox.Status:
+		out.BSSEnable2G = connectbox.WirelessEnabled
+		out.BssEnable5G = connectbox.WirelessDisabled
+		out.LANUserCount = "3"
+	default:
+		return fmt.Errorf("unexpected function: %s", fn)
+	}
+	return nil
+}
+
+func (r *fakeRouter) GuestNetwork(context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.guest, r.err
+}
+
+func (r *fakeRouter) Reboot(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reboots++
+	return r.err
+}
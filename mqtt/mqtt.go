@@ -0,0 +1,391 @@
+// Package mqtt provides a bridge between ConnectBox and Home Assistant
+// over MQTT, along with a minimal MQTT 3.1.1 client (QoS 0 only).
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// List of MQTT packet types.
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPuback     = 4
+	packetSubscribe  = 8
+	packetSuback     = 9
+	packetPingreq    = 12
+	packetPingresp   = 13
+	packetDisconnect = 14
+)
+
+// maxRemainingLength is the maximum packet size allowed by the protocol.
+const maxRemainingLength = 268435455
+
+// ErrClosed is returned when the connection is closed.
+var ErrClosed = errors.New("connection closed")
+
+// Options is a set of connection options.
+type Options struct {
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration
+	// Will is a message published by the broker when the connection
+	// is lost, it's optional
+	Will *Message
+}
+
+// Message is an MQTT message.
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Conn is a connection to MQTT broker.
+type Conn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	messages chan Message
+
+	// wmu serializes writes to the connection
+	wmu sync.Mutex
+	// mu guards packet IDs and pending subscriptions
+	mu      sync.Mutex
+	nextID  uint16
+	pending map[uint16]chan struct{}
+
+	done    chan struct{}
+	errOnce sync.Once
+	err     error
+}
+
+// Dial connects to the MQTT broker at `addr` (host:port).
+func Dial(ctx context.Context, addr string, opts Options) (*Conn, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+
+	c := &Conn{
+		conn:     nc,
+		reader:   bufio.NewReader(nc),
+		messages: make(chan Message, 16),
+		pending:  map[uint16]chan struct{}{},
+		done:     make(chan struct{}),
+	}
+	if err := c.connect(ctx, opts); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	go c.readLoop()
+	if opts.KeepAlive > 0 {
+		go c.pingLoop(opts.KeepAlive)
+	}
+
+	return c, nil
+}
+
+// Messages returns the channel of messages received on subscribed topics.
+// The channel is closed when the connection is closed.
+func (c *Conn) Messages() <-chan Message {
+	return c.messages
+}
+
+// Done returns a channel, which is closed when the connection is closed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the reason why the connection was closed.
+func (c *Conn) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Publish sends the message with QoS 0.
+func (c *Conn) Publish(msg Message) error {
+	var flags byte
+	if msg.Retain {
+		flags |= 0x01
+	}
+	body := appendString(nil, msg.Topic)
+	body = append(body, msg.Payload...)
+	return c.writePacket(packetPublish, flags, body)
+}
+
+// Subscribe subscribes to the topic filter with QoS 0, and waits for
+// the broker acknowledgement.
+func (c *Conn) Subscribe(ctx context.Context, topic string) error {
+	c.mu.Lock()
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID++
+	}
+	id := c.nextID
+	ack := make(chan struct{})
+	c.pending[id] = ack
+	c.mu.Unlock()
+
+	body := binary.BigEndian.AppendUint16(nil, id)
+	body = appendString(body, topic)
+	body = append(body, 0) // QoS 0
+	if err := c.writePacket(packetSubscribe, 0x02, body); err != nil {
+		return err
+	}
+
+	select {
+	case <-ack:
+		return nil
+	case <-c.done:
+		return c.err
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	}
+}
+
+// Close sends DISCONNECT packet and closes the connection.
+func (c *Conn) Close() error {
+	err := c.writePacket(packetDisconnect, 0, nil)
+	c.close(ErrClosed)
+	if errors.Is(err, ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (c *Conn) connect(ctx context.Context, opts Options) error {
+	var flags byte = 0x02 // clean session
+	body := appendString(nil, "MQTT")
+	body = append(body, 4) // protocol level 3.1.1
+
+	payload := appendString(nil, opts.ClientID)
+	if opts.Will != nil {
+		flags |= 0x04
+		if opts.Will.Retain {
+			flags |= 0x20
+		}
+		payload = appendString(payload, opts.Will.Topic)
+		payload = appendBytes(payload, opts.Will.Payload)
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, opts.Username)
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+		payload = appendString(payload, opts.Password)
+	}
+
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(opts.KeepAlive/time.Second))
+	body = append(body, payload...)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetDeadline(deadline)
+		defer c.conn.SetDeadline(time.Time{}) //nolint:errcheck
+	}
+
+	if err := c.writePacket(packetConnect, 0, body); err != nil {
+		return err
+	}
+	typ, _, resp, err := readPacket(c.reader)
+	if err != nil {
+		return fmt.Errorf("read connack: %w", err)
+	}
+	if typ != packetConnack || len(resp) != 2 {
+		return fmt.Errorf("unexpected packet: %d", typ)
+	}
+	if resp[1] != 0 {
+		return fmt.Errorf("connection refused: code %d", resp[1])
+	}
+	return nil
+}
+
+func (c *Conn) readLoop() {
+	defer close(c.messages)
+	for {
+		typ, flags, body, err := readPacket(c.reader)
+		if err != nil {
+			c.close(fmt.Errorf("read packet: %w", err))
+			return
+		}
+
+		switch typ {
+		case packetPublish:
+			msg, id, err := parsePublish(flags, body)
+			if err != nil {
+				c.close(err)
+				return
+			}
+			if id != 0 {
+				ack := binary.BigEndian.AppendUint16(nil, id)
+				if err := c.writePacket(packetPuback, 0, ack); err != nil {
+					return
+				}
+			}
+			select {
+			case c.messages <- msg:
+			case <-c.done:
+				return
+			}
+		case packetSuback:
+			if len(body) < 2 {
+				c.close(fmt.Errorf("invalid suback"))
+				return
+			}
+			id := binary.BigEndian.Uint16(body)
+			c.mu.Lock()
+			if ack, ok := c.pending[id]; ok {
+				close(ack)
+				delete(c.pending, id)
+			}
+			c.mu.Unlock()
+		case packetPingresp, packetPuback:
+		default:
+			c.close(fmt.Errorf("unexpected packet: %d", typ))
+			return
+		}
+	}
+}
+
+func (c *Conn) pingLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.writePacket(packetPingreq, 0, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *Conn) writePacket(typ, flags byte, body []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	if _, err := c.conn.Write(encodePacket(typ, flags, body)); err != nil {
+		c.close(fmt.Errorf("write packet: %w", err))
+		return fmt.Errorf("write packet: %w", err)
+	}
+	return nil
+}
+
+func (c *Conn) close(err error) {
+	c.errOnce.Do(func() {
+		c.err = err
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func encodePacket(typ, flags byte, body []byte) []byte {
+	buf := []byte{typ<<4 | flags}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(buf, body...)
+}
+
+func readPacket(r *bufio.Reader) (typ, flags byte, body []byte, err error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, nil, err //nolint:wrapcheck
+	}
+
+	var n, mul int = 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err //nolint:wrapcheck
+		}
+		n += int(b&0x7f) * mul
+		if n > maxRemainingLength {
+			return 0, 0, nil, fmt.Errorf("packet is too large")
+		}
+		if b&0x80 == 0 {
+			break
+		}
+		mul *= 128
+	}
+
+	body = make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, 0, nil, err //nolint:wrapcheck
+	}
+	return header >> 4, header & 0x0f, body, nil
+}
+
+func parsePublish(flags byte, body []byte) (msg Message, id uint16, err error) {
+	topic, rest, err := readString(body)
+	if err != nil {
+		return Message{}, 0, err
+	}
+	if qos := (flags >> 1) & 0x03; qos > 0 {
+		if len(rest) < 2 {
+			return Message{}, 0, fmt.Errorf("invalid publish packet")
+		}
+		id = binary.BigEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+	msg = Message{
+		Topic:   topic,
+		Payload: rest,
+		Retain:  flags&0x01 != 0,
+	}
+	return msg, id, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	return appendBytes(buf, []byte(s))
+}
+
+func appendBytes(buf, b []byte) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(b)))
+	return append(buf, b...)
+}
+
+func readString(buf []byte) (s string, rest []byte, err error) {
+	if len(buf) < 2 {
+		return "", nil, fmt.Errorf("invalid string")
+	}
+	n := int(binary.BigEndian.Uint16(buf))
+	if len(buf) < 2+n {
+		return "", nil, fmt.Errorf("invalid string")
+	}
+	return string(buf[2 : 2+n]), buf[2+n:], nil
+}
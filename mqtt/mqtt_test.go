@@ -0,0 +1,324 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConn(t *testing.T) {
+	t.Run("publish and subscribe", func(t *testing.T) {
+		b := newTestBroker(t)
+
+		sub, err := Dial(context.Background(), b.addr(), Options{ClientID: "sub"})
+		require.NoError(t, err)
+		defer sub.Close()
+		require.NoError(t, sub.Subscribe(context.Background(), "cb/#"))
+
+		pub, err := Dial(context.Background(), b.addr(), Options{ClientID: "pub"})
+		require.NoError(t, err)
+		defer pub.Close()
+		require.NoError(t, pub.Publish(Message{Topic: "cb/state", Payload: []byte("hello")}))
+
+		select {
+		case msg := <-sub.Messages():
+			require.Equal(t, Message{Topic: "cb/state", Payload: []byte("hello")}, msg)
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+	})
+
+	t.Run("retained message", func(t *testing.T) {
+		b := newTestBroker(t)
+
+		pub, err := Dial(context.Background(), b.addr(), Options{ClientID: "pub"})
+		require.NoError(t, err)
+		defer pub.Close()
+		msg := Message{Topic: "cb/availability", Payload: []byte("online"), Retain: true}
+		require.NoError(t, pub.Publish(msg))
+		b.waitRetained(t, "cb/availability")
+
+		sub, err := Dial(context.Background(), b.addr(), Options{ClientID: "sub"})
+		require.NoError(t, err)
+		defer sub.Close()
+		require.NoError(t, sub.Subscribe(context.Background(), "cb/availability"))
+
+		select {
+		case got := <-sub.Messages():
+			require.Equal(t, msg, got)
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+	})
+
+	t.Run("will and credentials", func(t *testing.T) {
+		b := newTestBroker(t)
+
+		will := &Message{Topic: "cb/availability", Payload: []byte("offline"), Retain: true}
+		conn, err := Dial(context.Background(), b.addr(), Options{
+			ClientID: "bridge",
+			Username: "user",
+			Password: "pass",
+			Will:     will,
+		})
+		require.NoError(t, err)
+		defer conn.Close()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		require.Equal(t, "user", b.username)
+		require.Equal(t, "pass", b.password)
+		require.Equal(t, will, b.will)
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		b := newTestBroker(t)
+		b.refuse = true
+
+		_, err := Dial(context.Background(), b.addr(), Options{ClientID: "cb"})
+		require.EqualError(t, err, "connection refused: code 5")
+	})
+
+	t.Run("closed connection", func(t *testing.T) {
+		b := newTestBroker(t)
+
+		conn, err := Dial(context.Background(), b.addr(), Options{ClientID: "cb"})
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+
+		<-conn.Done()
+		require.ErrorIs(t, conn.Err(), ErrClosed)
+		require.ErrorIs(t, conn.Publish(Message{Topic: "cb"}), ErrClosed)
+	})
+
+	t.Run("keepalive", func(t *testing.T) {
+		b := newTestBroker(t)
+
+		conn, err := Dial(context.Background(), b.addr(), Options{
+			ClientID:  "cb",
+			KeepAlive: 10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.Eventually(t, func() bool {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			return b.pings > 0
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestEncodePacket(t *testing.T) {
+	testCases := []struct {
+		name   string
+		size   int
+		header []byte
+	}{
+		{name: "empty", size: 0, header: []byte{0x30, 0x00}},
+		{name: "one byte", size: 127, header: []byte{0x30, 0x7f}},
+		{name: "two bytes", size: 128, header: []byte{0x30, 0x80, 0x01}},
+		{name: "three bytes", size: 16384, header: []byte{0x30, 0x80, 0x80, 0x01}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			body := make([]byte, tt.size)
+			packet := encodePacket(packetPublish, 0, body)
+			require.Equal(t, tt.header, packet[:len(tt.header)])
+
+			typ, flags, got, err := readPacket(bufio.NewReader(strings.NewReader(string(packet))))
+			require.NoError(t, err)
+			require.Equal(t, byte(packetPublish), typ)
+			require.Equal(t, byte(0), flags)
+			require.Equal(t, body, got)
+		})
+	}
+}
+
+// testBroker is a minimal MQTT broker that supports QoS 0, wildcard
+// subscriptions and retained messages.
+type testBroker struct {
+	ln     net.Listener
+	refuse bool
+
+	mu       sync.Mutex
+	subs     map[net.Conn][]string
+	retained map[string]Message
+	username string
+	password string
+	will     *Message
+	pings    int
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b := &testBroker{
+		ln:       ln,
+		subs:     map[net.Conn][]string{},
+		retained: map[string]Message{},
+	}
+	t.Cleanup(func() { ln.Close() })
+	go b.serve()
+	return b
+}
+
+func (b *testBroker) addr() string {
+	return b.ln.Addr().String()
+}
+
+// published returns retained message for the topic.
+func (b *testBroker) published(topic string) (Message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msg, ok := b.retained[topic]
+	return msg, ok
+}
+
+func (b *testBroker) waitRetained(t *testing.T, topic string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		_, ok := b.published(topic)
+		return ok
+	}, time.Second, 5*time.Millisecond)
+}
+
+func (b *testBroker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *testBroker) handle(conn net.Conn) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs, conn)
+		b.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		typ, flags, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch typ {
+		case packetConnect:
+			b.connect(body)
+			code := byte(0)
+			if b.refuse {
+				code = 5
+			}
+			b.write(conn, packetConnack, 0, []byte{0, code})
+		case packetPublish:
+			msg, _, err := parsePublish(flags, body)
+			if err != nil {
+				return
+			}
+			b.publish(msg)
+		case packetSubscribe:
+			id := body[:2]
+			topic, _, err := readString(body[2:])
+			if err != nil {
+				return
+			}
+			b.mu.Lock()
+			b.subs[conn] = append(b.subs[conn], topic)
+			var retained []Message
+			for _, msg := range b.retained {
+				if topicMatch(topic, msg.Topic) {
+					retained = append(retained, msg)
+				}
+			}
+			b.mu.Unlock()
+			b.write(conn, packetSuback, 0, append(id, 0))
+			for _, msg := range retained {
+				b.write(conn, packetPublish, 0x01, append(appendString(nil, msg.Topic), msg.Payload...))
+			}
+		case packetPingreq:
+			b.mu.Lock()
+			b.pings++
+			b.mu.Unlock()
+			b.write(conn, packetPingresp, 0, nil)
+		case packetDisconnect:
+			return
+		}
+	}
+}
+
+func (b *testBroker) connect(body []byte) {
+	// Skip protocol name and level
+	_, rest, _ := readString(body)
+	flags := rest[1]
+	rest = rest[4:]
+	_, rest, _ = readString(rest) // client ID
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if flags&0x04 != 0 {
+		var topic, payload string
+		topic, rest, _ = readString(rest)
+		payload, rest, _ = readString(rest)
+		b.will = &Message{Topic: topic, Payload: []byte(payload), Retain: flags&0x20 != 0}
+	}
+	if flags&0x80 != 0 {
+		b.username, rest, _ = readString(rest)
+	}
+	if flags&0x40 != 0 {
+		b.password, _, _ = readString(rest)
+	}
+}
+
+func (b *testBroker) publish(msg Message) {
+	b.mu.Lock()
+	if msg.Retain {
+		b.retained[msg.Topic] = msg
+	}
+	var conns []net.Conn
+	for conn, topics := range b.subs {
+		for _, topic := range topics {
+			if topicMatch(topic, msg.Topic) {
+				conns = append(conns, conn)
+				break
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	body := append(appendString(nil, msg.Topic), msg.Payload...)
+	for _, conn := range conns {
+		b.write(conn, packetPublish, 0, body)
+	}
+}
+
+func (b *testBroker) write(conn net.Conn, typ, flags byte, body []byte) {
+	_, _ = conn.Write(encodePacket(typ, flags, body))
+}
+
+// topicMatch checks if the topic matches the filter with "+" and "#"
+// wildcards.
+func topicMatch(filter, topic string) bool {
+	fs := strings.Split(filter, "/")
+	ts := strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return true
+		}
+		if i >= len(ts) || (f != "+" && f != ts[i]) {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
@@ -0,0 +1,159 @@
+package connectbox
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PasswordEncoder encodes the password before sending it to the router
+// on login. Firmware variants expect different encodings. Only schemes,
+// that don't need any data from the router, can be implemented as
+// a PasswordEncoder, e.g. a hash with a fixed salt, but not with a nonce
+// issued by the router.
+type PasswordEncoder func(password string) string
+
+var (
+	// PasswordPlain sends the password as is, which is expected by some
+	// firmwares.
+	PasswordPlain PasswordEncoder = func(p string) string { return p }
+	// PasswordSHA256 sends hex-encoded SHA256 hash of the password. This
+	// is the default.
+	PasswordSHA256 PasswordEncoder = hashPassword
+)
+
+// knownFirmwares maps firmware version prefixes (GlobalSettings.SwVersion)
+// to the password encoding they expect. Only the firmware, that the client
+// is tested with, is listed (see testdata), other ones can be added with
+// WithFirmwarePasswordEncoder.
+var knownFirmwares = map[string]PasswordEncoder{
+	"CH7465LG-NCIP-6": PasswordSHA256,
+}
+
+// autoDetectMaxFails is a maximum number of failed login attempts, after
+// which auto-detection doesn't try other encodings, so the account
+// doesn't get locked out.
+const autoDetectMaxFails = 2
+
+// WithPasswordEncoder sets the password encoding.
+func WithPasswordEncoder(e PasswordEncoder) Option {
+	return func(z *Client) {
+		z.encoder = e
+		z.autoDetect = false
+	}
+}
+
+// WithPasswordAutoDetect makes the client detect the password encoding
+// on the first login. If the firmware version is known, its encoding is
+// used. Otherwise SHA256 is tried first, and if the router rejects
+// the password, the plain one is tried unless there were too many failed
+// attempts already. The detected encoding is used for all following
+// logins.
+func WithPasswordAutoDetect() Option {
+	return func(z *Client) {
+		z.autoDetect = true
+	}
+}
+
+// WithFirmwarePasswordEncoder sets the password encoding for firmwares,
+// which version (GlobalSettings.SwVersion) starts with the prefix, and
+// enables auto-detection.
+func WithFirmwarePasswordEncoder(prefix string, e PasswordEncoder) Option {
+	return func(z *Client) {
+		if z.firmwares == nil {
+			z.firmwares = map[string]PasswordEncoder{}
+		}
+		z.firmwares[prefix] = e
+		z.autoDetect = true
+	}
+}
+
+// firmwareEncoder returns the password encoding expected by the firmware.
+// The longest matching prefix wins, encodings set by options take
+// precedence over the known ones.
+func (z *Client) firmwareEncoder(swVersion string) (PasswordEncoder, bool) {
+	for _, firmwares := range []map[string]PasswordEncoder{z.firmwares, knownFirmwares} {
+		var found PasswordEncoder
+		var length int
+		for prefix, enc := range firmwares {
+			if strings.HasPrefix(swVersion, prefix) && len(prefix) > length {
+				found, length = enc, len(prefix)
+			}
+		}
+		if found != nil {
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+// login sends the login request with the password encoded by the
+// configured encoder. With auto-detection the encoder is taken by
+// the firmware version from settings, which may be nil, and if it's
+// unknown, other encoders are tried when the password is rejected.
+func (z *Client) login(ctx context.Context, settings *GlobalSettings) (string, error) {
+	if !z.autoDetect {
+		return z.loginWith(ctx, z.encoder)
+	}
+
+	candidates := []PasswordEncoder{PasswordSHA256, PasswordPlain}
+	if settings != nil {
+		if enc, ok := z.firmwareEncoder(settings.SwVersion); ok {
+			candidates = []PasswordEncoder{enc}
+		}
+	}
+
+	var resp string
+	var err error
+	for i, enc := range candidates {
+		if i > 0 && !z.canRetryLogin(ctx) {
+			break
+		}
+		resp, err = z.loginWith(ctx, enc)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(resp, "success") {
+			z.encoder = enc
+			z.autoDetect = false
+			break
+		}
+	}
+	return resp, nil
+}
+
+// loginWith sends the login request with the password encoded by enc.
+func (z *Client) loginWith(ctx context.Context, enc PasswordEncoder) (string, error) {
+	args := xmlArgs{
+		{"Username", z.username},
+		{"Password", enc(z.secret)},
+	}
+	resp, err := z.xmlRequest(ctx, xmlSetter, FnLogin, args)
+	if err != nil {
+		return "", fmt.Errorf("xml request: %w", err)
+	}
+	return resp, nil
+}
+
+// canRetryLogin checks if one more login attempt is safe, i.e. the
+// number of failed attempts is known and small enough.
+func (z *Client) canRetryLogin(ctx context.Context) bool {
+	var fail Fail
+	if err := z.getUncached(ctx, FnFail, &fail); err != nil {
+		return false
+	}
+	count, err := strconv.Atoi(fail.FailCount)
+	if err != nil {
+		return false
+	}
+	return count < autoDetectMaxFails
+}
+
+func hashPassword(p string) string {
+	h := sha256.New()
+	h.Write([]byte(p))
+	sum := h.Sum(nil)
+	return fmt.Sprintf("%x", sum)
+}
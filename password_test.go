@@ -0,0 +1,267 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+const qwertySHA256 = "65e84be33532fb784c48129675f9eff3a682b27168c0ea744b2cf58ee02337c5"
+
+func TestWithPasswordEncoder(t *testing.T) {
+	testCases := []struct {
+		name    string
+		encoder PasswordEncoder
+		want    string
+	}{
+		{
+			name:    "plain",
+			encoder: PasswordPlain,
+			want:    "qwerty",
+		},
+		{
+			name:    "sha256",
+			encoder: PasswordSHA256,
+			want:    qwertySHA256,
+		},
+		{
+			name:    "custom",
+			encoder: func(p string) string { return "salt-" + p },
+			want:    "salt-qwerty",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			defer gock.Off()
+
+			client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+				WithPasswordEncoder(tt.encoder))
+			require.NoError(t, err)
+
+			gock.InterceptClient(client.http)
+
+			gock.New("http://127.0.0.1").
+				Get(loginPage).
+				Reply(http.StatusOK).
+				AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+			mockLockedOut(LockedOutDisabled)
+			gock.New("http://127.0.0.1").
+				Post(xmlSetter).
+				BodyString("fun=15&Username=bob&Password=" + tt.want + "$").
+				Reply(http.StatusOK).
+				BodyString("success;SID=sid1")
+
+			require.NoError(t, client.Login(context.Background()))
+			require.True(t, gock.IsDone())
+		})
+	}
+}
+
+func TestClient_LoginAutoDetect(t *testing.T) {
+	t.Run("known firmware", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithPasswordAutoDetect())
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockFirmware("CH7465LG-NCIP-6.15.30-1p3-1-NOSH")
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15&Username=bob&Password=" + qwertySHA256 + "$").
+			Reply(http.StatusOK).
+			BodyString("success;SID=sid1")
+
+		require.NoError(t, client.Login(context.Background()))
+		require.True(t, gock.IsDone())
+		require.False(t, client.autoDetect)
+	})
+
+	t.Run("firmware set by option", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithFirmwarePasswordEncoder("CH7465LG-NCIP-4", PasswordPlain))
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockFirmware("CH7465LG-NCIP-4.50.18.13-NOSH")
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15&Username=bob&Password=qwerty$").
+			Reply(http.StatusOK).
+			BodyString("success;SID=sid1")
+
+		require.NoError(t, client.Login(context.Background()))
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("known firmware rejects password", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithPasswordAutoDetect())
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		// Other encodings are not tried
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockFirmware("CH7465LG-NCIP-6.15.30-1p3-1-NOSH")
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15&Username=bob&Password=" + qwertySHA256 + "$").
+			Reply(http.StatusOK).
+			BodyString("fail")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=22$").
+			Reply(http.StatusOK).
+			BodyString("<fail><FailCount>1</FailCount></fail>")
+		mockLockedOut(LockedOutDisabled)
+
+		err = client.Login(context.Background())
+		require.EqualError(t, err, "invalid response: fail, failed attempts: 1")
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("try other encoding", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithPasswordAutoDetect())
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockFirmware("CH7465LG-NCIP-4.50.18.13-NOSH")
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15&Username=bob&Password=" + qwertySHA256 + "$").
+			Reply(http.StatusOK).
+			BodyString("fail")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=22$").
+			Reply(http.StatusOK).
+			BodyString("<fail><FailCount>1</FailCount></fail>")
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15&Username=bob&Password=qwerty$").
+			Reply(http.StatusOK).
+			BodyString("success;SID=sid1")
+
+		require.NoError(t, client.Login(context.Background()))
+		require.True(t, gock.IsDone())
+
+		// Detected encoding is used without trying others
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK)
+		mockFirmware("CH7465LG-NCIP-4.50.18.13-NOSH")
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15&Username=bob&Password=qwerty$").
+			Reply(http.StatusOK).
+			BodyString("success;SID=sid2")
+
+		require.NoError(t, client.Login(context.Background()))
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("too many failed attempts", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+			WithPasswordAutoDetect())
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+		mockFirmware("CH7465LG-NCIP-4.50.18.13-NOSH")
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=15&Username=bob&Password=" + qwertySHA256 + "$").
+			Reply(http.StatusOK).
+			BodyString("fail")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=22$").
+			Times(2).
+			Reply(http.StatusOK).
+			BodyString("<fail><FailCount>2</FailCount></fail>")
+		mockLockedOut(LockedOutDisabled)
+
+		err = client.Login(context.Background())
+		require.EqualError(t, err, "invalid response: fail, failed attempts: 2")
+		require.True(t, gock.IsDone())
+	})
+}
+
+func TestClient_FirmwareEncoder(t *testing.T) {
+	client, err := NewClient("http://127.0.0.1", "bob", "qwerty",
+		WithFirmwarePasswordEncoder("CH7465LG", PasswordPlain),
+		WithFirmwarePasswordEncoder("CH7465LG-NCIP-4", PasswordSHA256))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		version string
+		want    string
+		ok      bool
+	}{
+		{version: "CH7465LG-NCIP-6.15.30-1p3-1-NOSH", want: "qwerty", ok: true},
+		{version: "CH7465LG-NCIP-4.50.18.13-NOSH", want: qwertySHA256, ok: true},
+		{version: "TC7200.20", ok: false},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.version, func(t *testing.T) {
+			enc, ok := client.firmwareEncoder(tt.version)
+			require.Equal(t, tt.ok, ok)
+			if ok {
+				require.Equal(t, tt.want, enc("qwerty"))
+			}
+		})
+	}
+
+	// Known firmwares are used without options
+	client, err = NewClient("http://127.0.0.1", "bob", "qwerty")
+	require.NoError(t, err)
+	enc, ok := client.firmwareEncoder("CH7465LG-NCIP-6.15.30-1p3-1-NOSH")
+	require.True(t, ok)
+	require.Equal(t, qwertySHA256, enc("qwerty"))
+}
+
+// mockFirmware sets up gock mock for global settings with the firmware
+// version.
+func mockFirmware(version string) {
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=1$").
+		Reply(http.StatusOK).
+		BodyString("<GlobalSettings><SwVersion>" + version + "</SwVersion></GlobalSettings>")
+}
@@ -0,0 +1,193 @@
+package connectbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Plan is a list of changes required to bring the router configuration
+// to the desired state.
+type Plan struct {
+	Sections []PlanSection
+}
+
+// PlanSection is a list of changes in one section of the configuration.
+type PlanSection struct {
+	Section string
+	Changes []Change
+	// value is the desired section value merged with the live one,
+	// this is what will be sent to the router
+	value any
+}
+
+// Change is a field-level difference between two configurations.
+type Change struct {
+	Path string
+	Old  string
+	New  string
+}
+
+// Empty returns true if the plan has no changes.
+func (p *Plan) Empty() bool {
+	return len(p.Sections) == 0
+}
+
+// String returns a human-readable list of changes.
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes"
+	}
+	var b strings.Builder
+	for _, s := range p.Sections {
+		for _, c := range s.Changes {
+			fmt.Fprintf(&b, "%s.%s: %q -> %q\n", s.Section, c.Path, c.Old, c.New)
+		}
+	}
+	return b.String()
+}
+
+// Plan compares the desired configuration with the live one, and returns
+// the list of changes. Only non-empty sections of the desired config are
+// compared, and only non-empty fields in them are taken into account, so
+// the desired config may contain just the settings that matter. It also
+// means, that a field can't be cleared. Only writable sections, which is
+// the wireless one for now, may be set in the desired config, otherwise
+// ErrNotWritable is returned.
+func (z *Client) Plan(ctx context.Context, desired *Config) (*Plan, error) {
+	if desired.Version != ConfigVersion {
+		return nil, fmt.Errorf("unsupported config version: %d", desired.Version)
+	}
+	for _, s := range configSections {
+		if sectionValue(s, desired) != nil && !s.writable() {
+			return nil, fmt.Errorf("plan %s: %w", s.name, ErrNotWritable)
+		}
+	}
+
+	live := &Config{}
+	plan := &Plan{}
+	for _, s := range configSections {
+		want := sectionValue(s, desired)
+		if want == nil {
+			continue
+		}
+		if err := z.getSection(ctx, s, live); err != nil {
+			return nil, err
+		}
+		have := sectionValue(s, live)
+		value := merge(have, want)
+		changes := diff(have, value)
+		if len(changes) == 0 {
+			continue
+		}
+		plan.Sections = append(plan.Sections, PlanSection{
+			Section: s.name,
+			Changes: changes,
+			value:   value,
+		})
+	}
+	return plan, nil
+}
+
+// Apply pushes the changes from the plan to the router. Sections without
+// changes are never sent, so applying a plan built for an up-to-date
+// router does nothing. The plan must be built with Client.Plan, sections
+// of other plans are reported as failed.
+func (z *Client) Apply(ctx context.Context, plan *Plan) ([]SectionResult, error) {
+	var results []SectionResult
+	var errs []error
+	for _, s := range plan.Sections {
+		err := z.applySection(ctx, s)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		results = append(results, SectionResult{Section: s.Section, Err: err})
+	}
+	return results, errors.Join(errs...)
+}
+
+// applySection writes one section of the plan to the router.
+func (z *Client) applySection(ctx context.Context, s PlanSection) error {
+	section, ok := findSection(s.Section)
+	if !ok {
+		return fmt.Errorf("unknown section: %s", s.Section)
+	}
+	if s.value == nil {
+		return fmt.Errorf("set %s: no value, plan must be built with Client.Plan", s.Section)
+	}
+	return z.setSection(ctx, section, s.value)
+}
+
+// merge returns a copy of `base` with all non-zero fields of `override`
+// applied on top of it. Both values must be pointers to structs of the
+// same type. Slices are replaced as a whole.
+func merge(base, override any) any {
+	out := reflect.New(reflect.TypeOf(base).Elem())
+	out.Elem().Set(reflect.ValueOf(base).Elem())
+	mergeValue(out.Elem(), reflect.ValueOf(override).Elem())
+	return out.Interface()
+}
+
+func mergeValue(dst, src reflect.Value) {
+	if src.Kind() == reflect.Struct {
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Type().Field(i).IsExported() {
+				mergeValue(dst.Field(i), src.Field(i))
+			}
+		}
+		return
+	}
+	if !src.IsZero() {
+		dst.Set(src)
+	}
+}
+
+// diff returns the list of differences between two values of the same
+// type. Paths are built from the struct field names.
+func diff(from, to any) []Change {
+	var changes []Change
+	diffValue(&changes, "",
+		reflect.Indirect(reflect.ValueOf(from)),
+		reflect.Indirect(reflect.ValueOf(to)))
+	return changes
+}
+
+func diffValue(changes *[]Change, path string, from, to reflect.Value) {
+	switch from.Kind() { //nolint:exhaustive
+	case reflect.Struct:
+		for i := 0; i < from.NumField(); i++ {
+			field := from.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			diffValue(changes, joinPath(path, field.Name), from.Field(i), to.Field(i))
+		}
+	case reflect.Slice:
+		zero := reflect.Zero(from.Type().Elem())
+		for i := 0; i < max(from.Len(), to.Len()); i++ {
+			a, b := zero, zero
+			if i < from.Len() {
+				a = from.Index(i)
+			}
+			if i < to.Len() {
+				b = to.Index(i)
+			}
+			diffValue(changes, path+"["+strconv.Itoa(i)+"]", a, b)
+		}
+	default:
+		a, b := fmt.Sprint(from.Interface()), fmt.Sprint(to.Interface())
+		if a != b {
+			*changes = append(*changes, Change{Path: path, Old: a, New: b})
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
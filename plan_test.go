@@ -0,0 +1,234 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Plan(t *testing.T) {
+	t.Run("changes", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=300$").
+			Reply(http.StatusOK).
+			BodyString(`<WirelessBasic>
+				<BssEnable2g>1</BssEnable2g>
+				<SSID2G>home</SSID2G>
+				<PreSharedKey2g>secret</PreSharedKey2g>
+			</WirelessBasic>`)
+
+		desired := &Config{
+			Version:  ConfigVersion,
+			Wireless: &WirelessBasic1{SSID2G: "office"},
+		}
+		plan, err := client.Plan(context.Background(), desired)
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Len(t, plan.Sections, 1)
+		require.Equal(t, SectionWireless, plan.Sections[0].Section)
+		require.Equal(t, []Change{
+			{Path: "SSID2G", Old: "home", New: "office"},
+		}, plan.Sections[0].Changes)
+
+		// Unchanged settings are sent as they are
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301&wlBandMode2g=1&.*&wlSsid2g=office&.*&wlPSkey2g=secret&").
+			Reply(http.StatusOK)
+
+		results, err := client.Apply(context.Background(), plan)
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.Equal(t, []SectionResult{{Section: SectionWireless}}, results)
+	})
+
+	t.Run("backup-only section", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		// Nothing is requested from the router
+		desired := &Config{
+			Version:  ConfigVersion,
+			MTU:      &MTUSize{Size: "1400"},
+			Wireless: &WirelessBasic1{SSID2G: "office"},
+		}
+		_, err = client.Plan(context.Background(), desired)
+		require.ErrorIs(t, err, ErrNotWritable)
+		require.EqualError(t, err, "plan mtu: section is not writable")
+	})
+
+	t.Run("plan not built by client", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		plan := &Plan{Sections: []PlanSection{
+			{Section: "unknown"},
+			{Section: SectionWireless, Changes: []Change{{Path: "SSID2G", New: "home"}}},
+		}}
+		results, err := client.Apply(context.Background(), plan)
+		require.EqualError(t, err, "unknown section: unknown\n"+
+			"set wireless: no value, plan must be built with Client.Plan")
+		require.Len(t, results, 2)
+		require.Equal(t, "unknown", results[0].Section)
+		require.EqualError(t, results[0].Err, "unknown section: unknown")
+		require.Equal(t, SectionWireless, results[1].Section)
+		require.EqualError(t, results[1].Err,
+			"set wireless: no value, plan must be built with Client.Plan")
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=300$").
+			Reply(http.StatusOK).
+			BodyString("<WirelessBasic><SSID2G>home</SSID2G></WirelessBasic>")
+
+		desired := &Config{
+			Version:  ConfigVersion,
+			Wireless: &WirelessBasic1{SSID2G: "home"},
+		}
+		plan, err := client.Plan(context.Background(), desired)
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.True(t, plan.Empty())
+		require.Equal(t, "No changes", plan.String())
+
+		results, err := client.Apply(context.Background(), plan)
+		require.NoError(t, err)
+		require.Empty(t, results)
+	})
+
+	t.Run("failed to get live config", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=300$").
+			Reply(http.StatusInternalServerError)
+
+		desired := &Config{
+			Version:  ConfigVersion,
+			Wireless: &WirelessBasic1{SSID2G: "home"},
+		}
+		_, err = client.Plan(context.Background(), desired)
+		require.ErrorContains(t, err, "get wireless")
+	})
+}
+
+func TestPlan_String(t *testing.T) {
+	plan := &Plan{Sections: []PlanSection{{
+		Section: SectionWireless,
+		Changes: []Change{{Path: "SSID2G", Old: "home", New: "office"}},
+	}}}
+	require.Equal(t, "wireless.SSID2G: \"home\" -> \"office\"\n", plan.String())
+}
+
+func TestMerge(t *testing.T) {
+	base := &BasicDHCP{
+		LanIP:      "192.168.1.1",
+		SubnetMask: "255.255.255.0",
+		ReserveIPAddrs: []BasicDHCPReserveIPAddrs{
+			{MacAddress: "00:11:22:33:44:55", LeasedIP: "192.168.1.10"},
+		},
+	}
+	override := &BasicDHCP{
+		LanIP: "192.168.2.1",
+		ReserveIPAddrs: []BasicDHCPReserveIPAddrs{
+			{MacAddress: "00:11:22:33:44:66", LeasedIP: "192.168.2.10"},
+		},
+	}
+	expected := &BasicDHCP{
+		LanIP:      "192.168.2.1",
+		SubnetMask: "255.255.255.0",
+		ReserveIPAddrs: []BasicDHCPReserveIPAddrs{
+			{MacAddress: "00:11:22:33:44:66", LeasedIP: "192.168.2.10"},
+		},
+	}
+
+	require.Equal(t, expected, merge(base, override))
+	// Base value must not be modified
+	require.Equal(t, "192.168.1.1", base.LanIP)
+}
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name    string
+		from    any
+		to      any
+		changes []Change
+	}{
+		{
+			name: "equal",
+			from: &MTUSize{Size: "1500"},
+			to:   &MTUSize{Size: "1500"},
+		},
+		{
+			name:    "changed field",
+			from:    &MTUSize{Size: "1500"},
+			to:      &MTUSize{Size: "1400"},
+			changes: []Change{{Path: "Size", Old: "1500", New: "1400"}},
+		},
+		{
+			name: "removed element",
+			from: &BasicDHCP{ReserveIPAddrs: []BasicDHCPReserveIPAddrs{
+				{MacAddress: "00:11:22:33:44:55", LeasedIP: "192.168.1.10"},
+			}},
+			to: &BasicDHCP{},
+			changes: []Change{
+				{Path: "ReserveIPAddrs[0].MacAddress", Old: "00:11:22:33:44:55"},
+				{Path: "ReserveIPAddrs[0].LeasedIP", Old: "192.168.1.10"},
+			},
+		},
+		{
+			name: "changed list of strings",
+			from: &WANSetting{WANIPv4DNSAddr: []string{"8.8.8.8"}},
+			to:   &WANSetting{WANIPv4DNSAddr: []string{"1.1.1.1"}},
+			changes: []Change{
+				{Path: "WANIPv4DNSAddr[0]", Old: "8.8.8.8", New: "1.1.1.1"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.changes, diff(tc.from, tc.to))
+		})
+	}
+}
@@ -0,0 +1,372 @@
+// Package proxy provides a reverse proxy for ConnectBox, that owns the only
+// router session and shares it between many downstream clients.
+//
+// ConnectBox allows one logged in user at a time, and every login kicks out
+// the previous one. The proxy logs in once, and serves XML API requests
+// from all clients using its own session. Login and logout requests from
+// clients are answered by the proxy itself, session tokens and cookies are
+// rewritten, so clients never see the real ones.
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetafro/connectbox"
+)
+
+// List of cookie names.
+const (
+	sessionTokenName = "sessionToken"
+	sessionIDName    = "SID"
+)
+
+// List of XML API endpoints.
+const (
+	xmlGetter = "/xml/getter.xml"
+	xmlSetter = "/xml/setter.xml"
+)
+
+// defaultSessionTimeout is a default idle timeout of client sessions.
+const defaultSessionTimeout = 15 * time.Minute
+
+// publicFunctions are getters, that the router serves without a session.
+// Clients use them to check the lockout state before logging in.
+var publicFunctions = map[string]bool{
+	connectbox.FnGlobalSettings: true,
+	connectbox.FnFail:           true,
+	connectbox.FnLoginTimer:     true,
+}
+
+// Router is a part of connectbox.Client used by the proxy.
+type Router interface {
+	Login(ctx context.Context) error
+	Logout(ctx context.Context) error
+	GetRaw(ctx context.Context, fn string, args [][2]string) (string, error)
+	SetRaw(ctx context.Context, fn string, args [][2]string) (string, error)
+}
+
+// Config is a proxy configuration.
+type Config struct {
+	// Password is a password that clients must use to log in to the proxy,
+	// any password is accepted if it's empty
+	Password string
+	// SessionTimeout is a time after which idle client sessions expire,
+	// 15 minutes by default
+	SessionTimeout time.Duration
+	// OnError is called when a request to the router fails, it's optional
+	OnError func(error)
+}
+
+// Proxy is an HTTP handler, that forwards XML API requests to the router
+// using one shared session. XML API requests are served only to clients,
+// that are logged in to the proxy, except for a few public getters. Other
+// requests (web interface pages, scripts and styles) are forwarded as is.
+type Proxy struct {
+	router Router
+	cfg    Config
+	static *httputil.ReverseProxy
+
+	// mu serializes requests to the router, because the session token
+	// changes after each request
+	mu      sync.Mutex
+	session *connectbox.Session
+
+	// sessions holds the last activity time of sessions issued to clients
+	sessMu   sync.Mutex
+	sessions map[string]time.Time
+	now      func() time.Time
+}
+
+// New creates new proxy for the router. The address is used to forward
+// requests that are not a part of XML API.
+func New(router Router, addr string, cfg Config) (*Proxy, error) {
+	if !strings.HasPrefix(addr, "http") {
+		addr = "http://" + addr
+	}
+	target, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %s", addr)
+	}
+
+	if cfg.SessionTimeout == 0 {
+		cfg.SessionTimeout = defaultSessionTimeout
+	}
+	p := &Proxy{
+		router:   router,
+		cfg:      cfg,
+		session:  connectbox.NewSession(router.Login),
+		sessions: map[string]time.Time{},
+		now:      time.Now,
+	}
+	p.static = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			// Client cookies are fake, they must not reach the router
+			r.Out.Header.Del("Cookie")
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			// Router cookies must not reach clients
+			resp.Header.Del("Set-Cookie")
+			resp.Header.Add("Set-Cookie", newCookie(sessionTokenName, newToken()).String())
+			return nil
+		},
+	}
+	return p, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case xmlGetter, xmlSetter:
+		p.serveXML(w, r)
+	default:
+		// Pages may update the session token too, so they are
+		// serialized with XML requests
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.static.ServeHTTP(w, r)
+	}
+}
+
+// Close logs out from the router session.
+func (p *Proxy) Close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.session.LoggedIn() {
+		return nil
+	}
+	p.session.Reset()
+	if err := p.router.Logout(ctx); err != nil {
+		return fmt.Errorf("logout: %w", err)
+	}
+	return nil
+}
+
+func (p *Proxy) serveXML(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	args, err := parseArgs(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Token and function must be first arguments, the token is not
+	// checked, because it's replaced with the real one anyway
+	var fn string
+	var rest [][2]string
+	for _, arg := range args {
+		switch arg[0] {
+		case "token":
+		case "fun":
+			fn = arg[1]
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if fn == "" {
+		http.Error(w, "missing function", http.StatusBadRequest)
+		return
+	}
+
+	var resp string
+	switch {
+	case r.URL.Path == xmlSetter && fn == connectbox.FnLogin:
+		if !p.checkPassword(rest) {
+			http.Error(w, "invalid credentials", http.StatusForbidden)
+			return
+		}
+		sid, err := p.newSession()
+		if err != nil {
+			http.Error(w, "failed to create session", http.StatusInternalServerError)
+			return
+		}
+		resp = "successful;SID=" + sid
+		http.SetCookie(w, newCookie(sessionIDName, sid))
+	case r.URL.Path == xmlSetter && fn == connectbox.FnLogout:
+		// The real session is shared, so it's never closed by clients,
+		// only the client session is
+		if c, err := r.Cookie(sessionIDName); err == nil {
+			p.sessMu.Lock()
+			delete(p.sessions, c.Value)
+			p.sessMu.Unlock()
+		}
+	case !p.authorized(r, fn):
+		http.Error(w, "not logged in", http.StatusForbidden)
+		return
+	default:
+		resp, err = p.forward(r.Context(), r.URL.Path, fn, rest)
+		if err != nil {
+			if p.cfg.OnError != nil {
+				p.cfg.OnError(fmt.Errorf("fun=%s: %w", fn, err))
+			}
+			http.Error(w, "router request failed", http.StatusBadGateway)
+			return
+		}
+	}
+
+	http.SetCookie(w, newCookie(sessionTokenName, newToken()))
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, resp)
+}
+
+// forward sends the request to the router in the shared session. Getters
+// are retried after a new login, setters are not.
+func (p *Proxy) forward(ctx context.Context, path, fn string, args [][2]string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var resp string
+	send := func(ctx context.Context) error {
+		var err error
+		if path == xmlSetter {
+			resp, err = p.router.SetRaw(ctx, fn, args)
+		} else {
+			resp, err = p.router.GetRaw(ctx, fn, args)
+		}
+		if err != nil {
+			return fmt.Errorf("send request: %w", err)
+		}
+		return nil
+	}
+	do := p.session.Read
+	if path == xmlSetter {
+		do = p.session.Write
+	}
+	if err := do(ctx, send); err != nil {
+		return "", err
+	}
+	return resp, nil
+}
+
+// newSession issues a session ID to the client. Expired sessions are
+// removed at the same time, so the list doesn't grow.
+func (p *Proxy) newSession() (string, error) {
+	sid, err := newID()
+	if err != nil {
+		return "", err
+	}
+	p.sessMu.Lock()
+	defer p.sessMu.Unlock()
+	now := p.now()
+	for id, last := range p.sessions {
+		if now.Sub(last) > p.cfg.SessionTimeout {
+			delete(p.sessions, id)
+		}
+	}
+	p.sessions[sid] = now
+	return sid, nil
+}
+
+// authorized checks if the client may call the function, i.e. it's public,
+// or the client has a session issued by the proxy, that hasn't expired.
+func (p *Proxy) authorized(r *http.Request, fn string) bool {
+	if r.URL.Path == xmlGetter && publicFunctions[fn] {
+		return true
+	}
+	c, err := r.Cookie(sessionIDName)
+	if err != nil {
+		return false
+	}
+	p.sessMu.Lock()
+	defer p.sessMu.Unlock()
+	last, ok := p.sessions[c.Value]
+	if !ok {
+		return false
+	}
+	now := p.now()
+	if now.Sub(last) > p.cfg.SessionTimeout {
+		delete(p.sessions, c.Value)
+		return false
+	}
+	p.sessions[c.Value] = now
+	return true
+}
+
+// checkPassword checks the password from client login arguments. Clients
+// may send either SHA256 hash of the password, or the plain one, depending
+// on the firmware they expect.
+func (p *Proxy) checkPassword(args [][2]string) bool {
+	if p.cfg.Password == "" {
+		return true
+	}
+	for _, arg := range args {
+		if arg[0] != "Password" {
+			continue
+		}
+		for _, enc := range []connectbox.PasswordEncoder{
+			connectbox.PasswordSHA256,
+			connectbox.PasswordPlain,
+		} {
+			if subtle.ConstantTimeCompare([]byte(arg[1]), []byte(enc(p.cfg.Password))) == 1 {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// parseArgs parses url-encoded arguments keeping their order.
+func parseArgs(s string) ([][2]string, error) {
+	var args [][2]string
+	for _, item := range strings.Split(s, "&") {
+		if item == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(item, "=")
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument: %s", k)
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument value: %s", k)
+		}
+		args = append(args, [2]string{key, value})
+	}
+	return args, nil
+}
+
+// newID returns a random 128-bit ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("read random: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newToken returns a fake session token for clients.
+func newToken() string {
+	token, err := newID()
+	if err != nil {
+		return "0"
+	}
+	return token
+}
+
+func newCookie(name, value string) *http.Cookie {
+	return &http.Cookie{Name: name, Value: value, Path: "/"}
+}
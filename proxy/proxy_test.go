@@ -0,0 +1,455 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tetafro/connectbox"
+)
+
+func TestProxy(t *testing.T) {
+	t.Run("many clients share one session", func(t *testing.T) {
+		router := newTestRouter(t)
+		srv := newTestProxy(t, router, Config{})
+
+		for _, name := range []string{"exporter", "cli"} {
+			client, err := connectbox.NewClient(srv.URL, "NULL", "password")
+			require.NoError(t, err)
+			require.NoError(t, client.Login(context.Background()), name)
+
+			var mtu connectbox.MTUSize
+			err = client.Get(context.Background(), connectbox.FnMTUSize, &mtu)
+			require.NoError(t, err, name)
+			require.Equal(t, "1500", mtu.Size, name)
+
+			require.NoError(t, client.Logout(context.Background()), name)
+		}
+
+		require.Equal(t, 1, router.count("login"))
+		require.Equal(t, 0, router.count("logout"))
+		require.Equal(t, 2, router.count(connectbox.FnMTUSize))
+	})
+
+	t.Run("concurrent clients", func(t *testing.T) {
+		router := newTestRouter(t)
+		srv := newTestProxy(t, router, Config{})
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				client, err := connectbox.NewClient(srv.URL, "NULL", "password")
+				if err != nil {
+					errs <- err
+					return
+				}
+				if err := client.Login(context.Background()); err != nil {
+					errs <- err
+					return
+				}
+				var mtu connectbox.MTUSize
+				errs <- client.Get(context.Background(), connectbox.FnMTUSize, &mtu)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, router.count("login"))
+		require.Equal(t, 10, router.count(connectbox.FnMTUSize))
+	})
+
+	t.Run("setter", func(t *testing.T) {
+		router := newTestRouter(t)
+		srv := newTestProxy(t, router, Config{})
+
+		client, err := connectbox.NewClient(srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		require.NoError(t, client.Login(context.Background()))
+
+		_, err = client.SetRaw(context.Background(), connectbox.FnSetWirelessBasic,
+			[][2]string{{"wlSsid2g", "home"}})
+		require.NoError(t, err)
+		require.Regexp(t, `^token=router\d+&fun=301&wlSsid2g=home$`, router.lastBody())
+	})
+
+	t.Run("session closed by router", func(t *testing.T) {
+		router := newTestRouter(t)
+		srv := newTestProxy(t, router, Config{})
+
+		client, err := connectbox.NewClient(srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		require.NoError(t, client.Login(context.Background()))
+
+		var mtu connectbox.MTUSize
+		require.NoError(t, client.Get(context.Background(), connectbox.FnMTUSize, &mtu))
+
+		router.kick()
+		require.NoError(t, client.Get(context.Background(), connectbox.FnMTUSize, &mtu))
+		require.Equal(t, 2, router.count("login"))
+	})
+
+	t.Run("setter is not retried", func(t *testing.T) {
+		router := newTestRouter(t)
+		errs := make(chan error, 1)
+		srv := newTestProxy(t, router, Config{
+			OnError: func(err error) { errs <- err },
+		})
+
+		client, err := connectbox.NewClient(srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		require.NoError(t, client.Login(context.Background()))
+		var mtu connectbox.MTUSize
+		require.NoError(t, client.Get(context.Background(), connectbox.FnMTUSize, &mtu))
+
+		router.kick()
+		err = client.Reboot(context.Background())
+		require.ErrorContains(t, err, "invalid response status: 502")
+		require.ErrorContains(t, <-errs, "fun=8: send request")
+		require.Equal(t, 1, router.count("login"))
+		require.Equal(t, 0, router.count(connectbox.FnReboot))
+
+		// The next request logs in again
+		require.NoError(t, client.Get(context.Background(), connectbox.FnMTUSize, &mtu))
+		require.Equal(t, 2, router.count("login"))
+	})
+
+	t.Run("router error", func(t *testing.T) {
+		router := newTestRouter(t)
+		errs := make(chan error, 1)
+		srv := newTestProxy(t, router, Config{
+			OnError: func(err error) { errs <- err },
+		})
+
+		client, err := connectbox.NewClient(srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		require.NoError(t, client.Login(context.Background()))
+
+		router.mu.Lock()
+		router.down = true
+		router.mu.Unlock()
+
+		_, err = client.GetRaw(context.Background(), connectbox.FnMTUSize, nil)
+		require.ErrorContains(t, err, "invalid response status: 502")
+		require.ErrorContains(t, <-errs, "fun=134: login")
+	})
+
+	t.Run("password", func(t *testing.T) {
+		router := newTestRouter(t)
+		srv := newTestProxy(t, router, Config{Password: "secret"})
+
+		client, err := connectbox.NewClient(srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		require.ErrorContains(t, client.Login(context.Background()), "invalid response status: 403")
+
+		client, err = connectbox.NewClient(srv.URL, "NULL", "secret")
+		require.NoError(t, err)
+		require.NoError(t, client.Login(context.Background()))
+
+		client, err = connectbox.NewClient(srv.URL, "NULL", "secret",
+			connectbox.WithPasswordEncoder(connectbox.PasswordPlain))
+		require.NoError(t, err)
+		require.NoError(t, client.Login(context.Background()))
+	})
+
+	t.Run("not logged in", func(t *testing.T) {
+		router := newTestRouter(t)
+		srv := newTestProxy(t, router, Config{Password: "secret"})
+
+		post := func(path, body, sid string) (int, string) {
+			req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+			require.NoError(t, err)
+			if sid != "" {
+				req.AddCookie(&http.Cookie{Name: sessionIDName, Value: sid})
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			data, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return resp.StatusCode, string(data)
+		}
+
+		for _, tt := range []struct{ path, body, sid string }{
+			{path: xmlGetter, body: "token=1&fun=134"},
+			{path: xmlSetter, body: "token=1&fun=8"},
+			{path: xmlGetter, body: "token=1&fun=134", sid: "12345"},
+		} {
+			code, _ := post(tt.path, tt.body, tt.sid)
+			require.Equal(t, http.StatusForbidden, code, tt.body)
+		}
+		require.Equal(t, 0, router.count(connectbox.FnMTUSize))
+		require.Equal(t, 0, router.count(connectbox.FnReboot))
+
+		// Lockout state is public
+		code, _ := post(xmlGetter, "token=1&fun=1", "")
+		require.Equal(t, http.StatusOK, code)
+
+		code, _ = post(xmlSetter, "token=1&fun=15&Username=NULL&Password=wrong", "")
+		require.Equal(t, http.StatusForbidden, code)
+		code, resp := post(xmlSetter, "token=1&fun=15&Username=NULL&Password=secret", "")
+		require.Equal(t, http.StatusOK, code)
+		sid := strings.TrimPrefix(resp, "successful;SID=")
+		require.Regexp(t, `^[0-9a-f]{32}$`, sid)
+
+		code, resp = post(xmlGetter, "token=1&fun=134", sid)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "<mtusize><size>1500</size></mtusize>", resp)
+
+		// Session is closed by logout
+		code, _ = post(xmlSetter, "token=1&fun=16", sid)
+		require.Equal(t, http.StatusOK, code)
+		code, _ = post(xmlGetter, "token=1&fun=134", sid)
+		require.Equal(t, http.StatusForbidden, code)
+		require.Equal(t, 1, router.count(connectbox.FnMTUSize))
+	})
+
+	t.Run("idle session expires", func(t *testing.T) {
+		router := newTestRouter(t)
+		routerClient, err := connectbox.NewClient(router.srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		p, err := New(routerClient, router.srv.URL, Config{SessionTimeout: time.Minute})
+		require.NoError(t, err)
+		var mu sync.Mutex
+		now := time.Now()
+		p.now = func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			return now
+		}
+		wait := func(d time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			now = now.Add(d)
+		}
+		srv := httptest.NewServer(p)
+		defer srv.Close()
+
+		client, err := connectbox.NewClient(srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		require.NoError(t, client.Login(context.Background()))
+		idle, err := connectbox.NewClient(srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		require.NoError(t, idle.Login(context.Background()))
+
+		// Each request extends the session
+		var mtu connectbox.MTUSize
+		for i := 0; i < 3; i++ {
+			wait(50 * time.Second)
+			require.NoError(t, client.Get(context.Background(), connectbox.FnMTUSize, &mtu))
+		}
+
+		wait(2 * time.Minute)
+		err = client.Get(context.Background(), connectbox.FnMTUSize, &mtu)
+		require.ErrorContains(t, err, "invalid response status: 403")
+
+		// Sessions of idle clients are removed on the next login
+		require.NoError(t, client.Login(context.Background()))
+		p.sessMu.Lock()
+		defer p.sessMu.Unlock()
+		require.Len(t, p.sessions, 1)
+	})
+
+	t.Run("static pages", func(t *testing.T) {
+		router := newTestRouter(t)
+		srv := newTestProxy(t, router, Config{})
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/index.html", nil)
+		require.NoError(t, err)
+		req.AddCookie(&http.Cookie{Name: sessionTokenName, Value: "fake"})
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "<html></html>", string(body))
+		require.Empty(t, router.lastCookie())
+
+		cookies := resp.Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, sessionTokenName, cookies[0].Name)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		router := newTestRouter(t)
+		client, err := connectbox.NewClient(router.srv.URL, "NULL", "password")
+		require.NoError(t, err)
+		p, err := New(client, router.srv.URL, Config{})
+		require.NoError(t, err)
+
+		// Nothing to close before the first request
+		require.NoError(t, p.Close(context.Background()))
+		require.Equal(t, 0, router.count("logout"))
+
+		_, err = p.forward(context.Background(), xmlGetter, connectbox.FnMTUSize, nil)
+		require.NoError(t, err)
+		require.NoError(t, p.Close(context.Background()))
+		require.Equal(t, 1, router.count("logout"))
+	})
+}
+
+func TestParseArgs(t *testing.T) {
+	args, err := parseArgs("token=1&fun=15&Username=NULL&Password=a%2Bb")
+	require.NoError(t, err)
+	require.Equal(t, [][2]string{
+		{"token", "1"},
+		{"fun", "15"},
+		{"Username", "NULL"},
+		{"Password", "a+b"},
+	}, args)
+
+	_, err = parseArgs("token=%zz")
+	require.EqualError(t, err, "invalid argument value: token")
+}
+
+func FuzzParseArgs(f *testing.F) {
+	f.Add("token=1&fun=15&Username=NULL&Password=a%2Bb")
+	f.Add("token=%zz")
+	f.Add("&&fun&=")
+	f.Fuzz(func(t *testing.T, s string) {
+		args, err := parseArgs(s)
+		if err != nil {
+			return
+		}
+		// Encoded arguments are parsed back to the same values
+		items := make([]string, len(args))
+		for i, arg := range args {
+			items[i] = url.QueryEscape(arg[0]) + "=" + url.QueryEscape(arg[1])
+		}
+		again, err := parseArgs(strings.Join(items, "&"))
+		require.NoError(t, err)
+		require.Equal(t, args, again)
+	})
+}
+
+func newTestProxy(t *testing.T, router *testRouter, cfg Config) *httptest.Server {
+	t.Helper()
+	client, err := connectbox.NewClient(router.srv.URL, "NULL", "password")
+	require.NoError(t, err)
+	p, err := New(client, router.srv.URL, cfg)
+	require.NoError(t, err)
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testRouter imitates ConnectBox session handling: the token changes
+// after each request, and only the last issued token is valid.
+type testRouter struct {
+	srv *httptest.Server
+
+	mu     sync.Mutex
+	token  string
+	sid    string
+	down   bool
+	calls  map[string]int
+	body   string
+	cookie string
+}
+
+func newTestRouter(t *testing.T) *testRouter {
+	t.Helper()
+	r := &testRouter{calls: map[string]int{}}
+	r.srv = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+func (r *testRouter) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.down {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.cookie = req.Header.Get("Cookie")
+	if req.Method == http.MethodGet {
+		// Initial token is issued on pages before logging in
+		if r.sid == "" {
+			r.rotate(w)
+		}
+		_, _ = io.WriteString(w, "<html></html>")
+		return
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	r.body = string(body)
+	args, _ := parseArgs(r.body)
+	token, fn := args[0][1], args[1][1]
+	if token != r.token {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case fn == connectbox.FnLogin:
+		r.calls["login"]++
+		r.sid = strconv.Itoa(r.calls["login"])
+		r.rotate(w)
+		_, _ = io.WriteString(w, "successful;SID="+r.sid)
+	case fn == connectbox.FnLogout:
+		r.calls["logout"]++
+		r.sid = ""
+		r.rotate(w)
+	case fn == connectbox.FnGlobalSettings:
+		// Lockout state is available without a session
+		r.rotate(w)
+		_, _ = io.WriteString(w, "<GlobalSettings><LockedOut>Disable</LockedOut></GlobalSettings>")
+	case r.sid == "" || !strings.Contains(r.cookie, "SID="+r.sid):
+		w.WriteHeader(http.StatusForbidden)
+	default:
+		r.calls[fn]++
+		r.rotate(w)
+		if fn == connectbox.FnMTUSize {
+			_, _ = io.WriteString(w, "<mtusize><size>1500</size></mtusize>")
+		}
+	}
+}
+
+func (r *testRouter) rotate(w http.ResponseWriter) {
+	r.calls["token"]++
+	r.token = fmt.Sprintf("router%d", r.calls["token"])
+	http.SetCookie(w, &http.Cookie{Name: sessionTokenName, Value: r.token, Path: "/"})
+}
+
+// kick closes the session, like when someone else logs in.
+func (r *testRouter) kick() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sid = ""
+}
+
+func (r *testRouter) count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[name]
+}
+
+func (r *testRouter) lastBody() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body
+}
+
+func (r *testRouter) lastCookie() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cookie
+}
@@ -0,0 +1,85 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// waitOnlineInterval is a pause between status checks in WaitOnline.
+var waitOnlineInterval = 5 * time.Second
+
+// Reboot restarts the router. The router drops all connections and becomes
+// unreachable for a few minutes, so the current session is lost, and
+// Login must be called again afterwards (WaitOnline does it).
+func (z *Client) Reboot(ctx context.Context) error {
+	start := time.Now()
+	_, err := z.xmlRequest(ctx, xmlSetter, FnReboot, xmlArgs{})
+	if err != nil {
+		return fmt.Errorf("xml request: %w", err)
+	}
+	z.rebootedAt = start
+	return nil
+}
+
+// WaitOnline blocks until the router is operational and has internet
+// access, or until the context is done. It's meant to be called after
+// Reboot: connection errors are tolerated, and the client logs in again
+// once the router is reachable. The router doesn't go down right after
+// the reboot request, so after Reboot it's only reported online when its
+// uptime shows, that it has been restarted since the request.
+func (z *Client) WaitOnline(ctx context.Context) error {
+	var lastErr error
+	loggedIn := false
+	for {
+		if !loggedIn {
+			lastErr = z.Login(ctx)
+			loggedIn = lastErr == nil
+		}
+		if loggedIn {
+			var online bool
+			online, lastErr = z.isOnline(ctx)
+			if online {
+				return nil
+			}
+			// Any error means the session may have been reset
+			loggedIn = lastErr == nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("wait online: %w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return fmt.Errorf("wait online: %w", ctx.Err())
+		case <-time.After(waitOnlineInterval):
+		}
+	}
+}
+
+func (z *Client) isOnline(ctx context.Context) (bool, error) {
+	var state CMState
+	if err := z.Get(ctx, FnCMState, &state); err != nil {
+		return false, fmt.Errorf("get cm state: %w", err)
+	}
+	if state.OperState != OperStateOK {
+		return false, nil
+	}
+
+	var info CMSystemInfo
+	if err := z.Get(ctx, FnCMSystemInfo, &info); err != nil {
+		return false, fmt.Errorf("get cm system info: %w", err)
+	}
+	if !z.rebootedAt.IsZero() && !bootedSince(info.SystemUptime, z.rebootedAt) {
+		return false, nil
+	}
+	z.rebootedAt = time.Time{}
+	return info.NetworkAccess == NetworkAccessAllowed, nil
+}
+
+// bootedSince checks if the router with the uptime (in seconds) has been
+// booted after the given time. Uptime is rounded down to seconds by
+// the router, so one second is added to the elapsed time.
+func bootedSince(uptime int, t time.Time) bool {
+	return time.Duration(uptime)*time.Second <= time.Since(t)+time.Second
+}
@@ -0,0 +1,190 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Reboot(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("token=token1&fun=8").
+			Reply(http.StatusOK)
+
+		err = client.Reboot(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("token=token1&fun=8").
+			Reply(http.StatusInternalServerError)
+
+		err = client.Reboot(context.Background())
+		require.ErrorContains(t, err, "invalid response status")
+	})
+}
+
+func TestClient_WaitOnline(t *testing.T) {
+	defaultInterval := waitOnlineInterval
+	waitOnlineInterval = time.Millisecond
+	defer func() { waitOnlineInterval = defaultInterval }()
+
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		// Router is still booting
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Reply(http.StatusInternalServerError)
+		// Router is up, but not operational yet
+		mockLogin()
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=136").
+			Reply(http.StatusOK).
+			BodyString(`<cm_state><OperState>NOT_READY</OperState></cm_state>`)
+		// Router is operational
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=136").
+			Reply(http.StatusOK).
+			BodyString(`<cm_state><OperState>OPERATIONAL</OperState></cm_state>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=2").
+			Reply(http.StatusOK).
+			BodyString(`<cm_system_info>` +
+				`<cm_system_uptime>0day(s)0h:3m:15s</cm_system_uptime>` +
+				`<cm_network_access>Allowed</cm_network_access>` +
+				`</cm_system_info>`)
+
+		err = client.WaitOnline(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("router is still up after reboot", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=8").
+			Reply(http.StatusOK)
+		require.NoError(t, client.Reboot(context.Background()))
+		// The router takes a few minutes to restart
+		client.rebootedAt = client.rebootedAt.Add(-5 * time.Minute)
+
+		// Router hasn't gone down yet
+		mockLogin()
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=136").
+			Reply(http.StatusOK).
+			BodyString(`<cm_state><OperState>OPERATIONAL</OperState></cm_state>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=2").
+			Reply(http.StatusOK).
+			BodyString(`<cm_system_info>` +
+				`<cm_system_uptime>12day(s)5h:10m:15s</cm_system_uptime>` +
+				`<cm_network_access>Allowed</cm_network_access>` +
+				`</cm_system_info>`)
+		// Router has restarted
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=136").
+			Reply(http.StatusOK).
+			BodyString(`<cm_state><OperState>OPERATIONAL</OperState></cm_state>`)
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=2").
+			Reply(http.StatusOK).
+			BodyString(`<cm_system_info>` +
+				`<cm_system_uptime>0day(s)0h:3m:15s</cm_system_uptime>` +
+				`<cm_network_access>Allowed</cm_network_access>` +
+				`</cm_system_info>`)
+
+		err = client.WaitOnline(context.Background())
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+		require.True(t, client.rebootedAt.IsZero())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Get(loginPage).
+			Persist().
+			Reply(http.StatusInternalServerError)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err = client.WaitOnline(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, "get initial token")
+	})
+}
+
+func TestBootedSince(t *testing.T) {
+	rebootedAt := time.Now().Add(-time.Minute)
+	require.True(t, bootedSince(30, rebootedAt))
+	require.True(t, bootedSince(60, rebootedAt))
+	require.False(t, bootedSince(3600, rebootedAt))
+}
+
+// mockLogin sets up gock mocks for a successful login.
+func mockLogin() {
+	gock.New("http://127.0.0.1").
+		Get(loginPage).
+		Reply(http.StatusOK).
+		AddHeader("Set-Cookie", "sessionToken=token1; Path=/")
+	mockLockedOut(LockedOutDisabled)
+	gock.New("http://127.0.0.1").
+		Post(xmlSetter).
+		BodyString("fun=15").
+		Reply(http.StatusOK).
+		AddHeader("Set-Cookie", "sessionToken=token2; Path=/").
+		BodyString("success;SID=sid1")
+}
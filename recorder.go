@@ -0,0 +1,135 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// List of recorded metrics.
+const (
+	MetricDownstreamPower  = "downstream_power"
+	MetricDownstreamSNR    = "downstream_snr"
+	MetricUpstreamPower    = "upstream_power"
+	MetricUnerrored        = "unerrored"
+	MetricCorrectable      = "correctable"
+	MetricUncorrectable    = "uncorrectable"
+	MetricTemperature      = "temperature"
+	MetricTunerTemperature = "tuner_temperature"
+	MetricClients          = "clients"
+)
+
+// Sample is a single measurement of a metric. Channel is a channel ID for
+// per-channel metrics, or a group name (e.g. "wifi" for clients).
+type Sample struct {
+	Time    time.Time `json:"t"`
+	Metric  string    `json:"m"`
+	Channel string    `json:"c,omitempty"`
+	Value   float64   `json:"v"`
+}
+
+// Recorder periodically reads modem metrics and saves them to the store.
+type Recorder struct {
+	client   *Client
+	store    *FileStore
+	interval time.Duration
+}
+
+// NewRecorder creates new recorder.
+func NewRecorder(client *Client, store *FileStore, interval time.Duration) *Recorder {
+	return &Recorder{
+		client:   client,
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run records metrics every interval until the context is done. Old data
+// is downsampled and removed according to the store settings. Failed
+// requests to the router are reported to onError, which may be nil, and
+// don't stop recording. Run stops only when the context is done, or when
+// the store fails.
+func (r *Recorder) Run(ctx context.Context, onError func(error)) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		m, err := r.client.Metrics(ctx)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return ctx.Err() //nolint:wrapcheck
+		case err != nil:
+			if onError != nil {
+				onError(fmt.Errorf("get metrics: %w", err))
+			}
+		default:
+			if err := r.save(m); err != nil {
+				return err
+			}
+		}
+		if err := r.store.Compact(time.Now()); err != nil {
+			return fmt.Errorf("compact: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
+		case <-ticker.C:
+		}
+	}
+}
+
+// Record reads metrics once and saves them to the store.
+func (r *Recorder) Record(ctx context.Context) error {
+	m, err := r.client.Metrics(ctx)
+	if err != nil {
+		return fmt.Errorf("get metrics: %w", err)
+	}
+	return r.save(m)
+}
+
+// save appends metrics to the store.
+func (r *Recorder) save(m *MetricsSnapshot) error {
+	if err := r.store.Append(collectSamples(m)); err != nil {
+		return fmt.Errorf("append samples: %w", err)
+	}
+	return nil
+}
+
+// collectSamples converts metrics to the list of samples. Values that
+// can't be parsed as numbers are skipped.
+func collectSamples(m *MetricsSnapshot) []Sample {
+	var samples []Sample
+	add := func(metric, channel, value string) {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return
+		}
+		samples = append(samples, Sample{
+			Time:    m.Time,
+			Metric:  metric,
+			Channel: channel,
+			Value:   v,
+		})
+	}
+
+	for _, d := range m.Downstream.Downstreams {
+		add(MetricDownstreamPower, d.Chid, d.Pow)
+		add(MetricDownstreamSNR, d.Chid, d.Snr)
+	}
+	for _, u := range m.Upstream.Upstreams {
+		add(MetricUpstreamPower, u.Usid, u.Power)
+	}
+	for _, s := range m.Signal.Signals {
+		add(MetricUnerrored, s.Dsid, s.Unerrored)
+		add(MetricCorrectable, s.Dsid, s.Correctable)
+		add(MetricUncorrectable, s.Dsid, s.Uncorrectable)
+	}
+	add(MetricTemperature, "", strconv.Itoa(m.State.Temperature))
+	add(MetricTunerTemperature, "", strconv.Itoa(m.State.TunnerTemperature))
+	add(MetricClients, bandEthernet, strconv.Itoa(len(m.LANUsers.Ethernet)))
+	add(MetricClients, bandWIFI, strconv.Itoa(len(m.LANUsers.WIFI)))
+
+	return samples
+}
@@ -0,0 +1,180 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder_Record(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+		mockMetrics()
+
+		store, err := NewFileStore(t.TempDir(), FileStoreConfig{})
+		require.NoError(t, err)
+
+		start := time.Now()
+		rec := NewRecorder(client, store, time.Minute)
+		require.NoError(t, rec.Record(context.Background()))
+		require.True(t, gock.IsDone())
+
+		samples, err := store.Query(MetricDownstreamPower, "", start, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, samples, 1)
+		require.Equal(t, "1", samples[0].Channel)
+		require.Equal(t, 6.0, samples[0].Value)
+
+		samples, err = store.Query(MetricClients, "wifi", start, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Len(t, samples, 1)
+		require.Equal(t, 2.0, samples[0].Value)
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=10$").
+			Reply(http.StatusInternalServerError)
+
+		store, err := NewFileStore(t.TempDir(), FileStoreConfig{})
+		require.NoError(t, err)
+
+		rec := NewRecorder(client, store, time.Minute)
+		err = rec.Record(context.Background())
+		require.ErrorContains(t, err, "get metrics: get downstream table")
+	})
+}
+
+func TestRecorder_Run(t *testing.T) {
+	defer gock.Off()
+
+	client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+	require.NoError(t, err)
+	client.token = "token1"
+
+	gock.InterceptClient(client.http)
+
+	// The first poll fails, the second one is recorded
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=10$").
+		Reply(http.StatusInternalServerError)
+	mockMetrics()
+
+	store, err := NewFileStore(t.TempDir(), FileStoreConfig{})
+	require.NoError(t, err)
+
+	start := time.Now()
+	errs := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	rec := NewRecorder(client, store, 5*time.Millisecond)
+	go func() {
+		done <- rec.Run(ctx, func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+	}()
+
+	require.Eventually(t, func() bool {
+		samples, err := store.Query(MetricDownstreamPower, "", start, time.Now().Add(time.Second))
+		return err == nil && len(samples) == 1
+	}, time.Second, 5*time.Millisecond)
+	require.ErrorContains(t, <-errs, "get metrics: get downstream table")
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestCollectSamples(t *testing.T) {
+	now := time.Date(2023, 9, 20, 10, 0, 0, 0, time.UTC)
+	samples := collectSamples(&MetricsSnapshot{
+		Time: now,
+		Downstream: &DownstreamTable{Downstreams: []DownstreamTableDownstream{
+			{Chid: "1", Pow: "6", Snr: "n/a"},
+		}},
+		Upstream: &UpstreamTable{Upstreams: []UpstreamTableUpstream{
+			{Usid: "2", Power: "41"},
+		}},
+		Signal: &SignalTable{Signals: []SignalTableSignal{
+			{Dsid: "1", Unerrored: "100", Correctable: "10", Uncorrectable: "1"},
+		}},
+		State:    &CMState{Temperature: 40, TunnerTemperature: 45},
+		LANUsers: &LANUserTable{Ethernet: []LANUserTableEthernet{{}}},
+	})
+	require.Equal(t, []Sample{
+		{Time: now, Metric: MetricDownstreamPower, Channel: "1", Value: 6},
+		{Time: now, Metric: MetricUpstreamPower, Channel: "2", Value: 41},
+		{Time: now, Metric: MetricUnerrored, Channel: "1", Value: 100},
+		{Time: now, Metric: MetricCorrectable, Channel: "1", Value: 10},
+		{Time: now, Metric: MetricUncorrectable, Channel: "1", Value: 1},
+		{Time: now, Metric: MetricTemperature, Value: 40},
+		{Time: now, Metric: MetricTunerTemperature, Value: 45},
+		{Time: now, Metric: MetricClients, Channel: "ethernet", Value: 1},
+		{Time: now, Metric: MetricClients, Channel: "wifi", Value: 0},
+	}, samples)
+}
+
+// mockMetrics sets up gock mocks for all getters used by the recorder.
+func mockMetrics() {
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=10$").
+		Reply(http.StatusOK).
+		BodyString(`<downstream_table><downstream>
+			<pow>6</pow><snr>38</snr><mod>256qam</mod><chid>1</chid>
+		</downstream></downstream_table>`)
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=11$").
+		Reply(http.StatusOK).
+		BodyString(`<upstream_table><upstream>
+			<usid>2</usid><power>41</power>
+		</upstream></upstream_table>`)
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=12$").
+		Reply(http.StatusOK).
+		BodyString(`<signal_table><signal>
+			<dsid>1</dsid><unerrored>1000</unerrored>
+			<correctable>10</correctable><uncorrectable>0</uncorrectable>
+		</signal></signal_table>`)
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=136$").
+		Reply(http.StatusOK).
+		BodyString(`<cmstate>
+			<TunnerTemperature>100</TunnerTemperature>
+			<Temperature>104</Temperature>
+			<OperState>OPERATIONAL</OperState>
+		</cmstate>`)
+	gock.New("http://127.0.0.1").
+		Post(xmlGetter).
+		BodyString("fun=123$").
+		Reply(http.StatusOK).
+		BodyString(`<LanUserTable>
+			<WIFI><clientinfo><index>1</index></clientinfo>
+			<clientinfo><index>2</index></clientinfo></WIFI>
+		</LanUserTable>`)
+}
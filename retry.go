@@ -0,0 +1,121 @@
+package connectbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrBusy is returned when the router keeps responding with empty body
+// after all retry attempts.
+var ErrBusy = errors.New("router is busy")
+
+// StatusError is returned when the router responds with unexpected HTTP
+// status.
+type StatusError struct {
+	Code int
+}
+
+// Error implements error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("invalid response status: %d", e.Code)
+}
+
+// RetryPolicy defines how failed XML API requests are retried. The router
+// often responds with HTTP 500 or empty body when it's busy, or right
+// after boot.
+type RetryPolicy struct {
+	// MaxAttempts is a total number of attempts, including the first one
+	MaxAttempts int
+	// InitialBackoff is a delay before the first retry, it's doubled for
+	// each next retry
+	InitialBackoff time.Duration
+	// MaxBackoff is a maximum delay between attempts, 0 means no limit
+	MaxBackoff time.Duration
+	// Jitter is a fraction of the delay (0 to 1), that is randomly
+	// subtracted from it
+	Jitter float64
+	// RetryableStatuses is a set of HTTP statuses to retry
+	RetryableStatuses []int
+	// RetryEmpty enables retries of getters that return empty body
+	RetryEmpty bool
+	// RetrySetters enables retries of setters. Setters are not idempotent,
+	// e.g. a failed request may still be applied by the router, so they
+	// are not retried by default.
+	RetrySetters bool
+}
+
+// DefaultRetryPolicy is a retry policy, that suits most routers.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Jitter:         0.5,
+	RetryableStatuses: []int{
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+	RetryEmpty: true,
+}
+
+// WithRetry enables retries of XML API requests.
+func WithRetry(p RetryPolicy) Option {
+	return func(z *Client) {
+		p.RetryableStatuses = slices.Clone(p.RetryableStatuses)
+		z.retry = &p
+	}
+}
+
+// isBusy checks if the request result means that the router is busy,
+// and the request should be retried.
+func (p *RetryPolicy) isBusy(ctx context.Context, path, resp string, err error) bool {
+	if err == nil {
+		return p.RetryEmpty && path == xmlGetter && strings.TrimSpace(resp) == ""
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return slices.Contains(p.RetryableStatuses, statusErr.Code)
+	}
+	// Network errors, e.g. the router is still booting
+	return true
+}
+
+// wait sleeps before the next attempt.
+func (p *RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff returns the delay after the attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * min(p.Jitter, 1) * float64(d)) //nolint:gosec
+	}
+	return d
+}
@@ -0,0 +1,207 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Retry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:       3,
+		InitialBackoff:    time.Millisecond,
+		RetryableStatuses: []int{http.StatusInternalServerError},
+		RetryEmpty:        true,
+	}
+
+	t.Run("retryable status", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithRetry(policy))
+		require.NoError(t, err)
+		client.setCookie(sessionTokenName, "token1")
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("token=token1&fun=134$").
+			Reply(http.StatusInternalServerError).
+			AddHeader("Set-Cookie", "sessionToken=token2; Path=/")
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("token=token2&fun=134$").
+			Reply(http.StatusOK).
+			AddHeader("Set-Cookie", "sessionToken=token3; Path=/").
+			BodyString("<mtusize><size>1500</size></mtusize>")
+
+		var data MTUSize
+		require.NoError(t, client.Get(context.Background(), FnMTUSize, &data))
+		require.Equal(t, MTUSize{Size: "1500"}, data)
+		require.Equal(t, "token3", client.token)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithRetry(policy))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Times(3).
+			Reply(http.StatusOK)
+
+		var data MTUSize
+		err = client.Get(context.Background(), FnMTUSize, &data)
+		require.ErrorIs(t, err, ErrBusy)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("attempts exceeded", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithRetry(policy))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Times(3).
+			Reply(http.StatusInternalServerError)
+
+		var data MTUSize
+		err = client.Get(context.Background(), FnMTUSize, &data)
+		var statusErr *StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, http.StatusInternalServerError, statusErr.Code)
+		require.EqualError(t, err, "get response: invalid response status: 500")
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("not retryable status", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithRetry(policy))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Times(1).
+			Reply(http.StatusForbidden)
+
+		var data MTUSize
+		err = client.Get(context.Background(), FnMTUSize, &data)
+		require.ErrorContains(t, err, "invalid response status: 403")
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("setter is not retried", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithRetry(policy))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301&wlSsid2g=home$").
+			Times(1).
+			Reply(http.StatusInternalServerError)
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301&wlSsid2g=home$").
+			Reply(http.StatusOK)
+
+		_, err = client.SetRaw(context.Background(), FnSetWirelessBasic, [][2]string{{"wlSsid2g", "home"}})
+		require.ErrorContains(t, err, "invalid response status: 500")
+		require.False(t, gock.IsDone())
+	})
+
+	t.Run("setter retried when enabled", func(t *testing.T) {
+		defer gock.Off()
+
+		p := policy
+		p.RetrySetters = true
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithRetry(p))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301&wlSsid2g=home$").
+			Times(1).
+			Reply(http.StatusInternalServerError)
+		gock.New("http://127.0.0.1").
+			Post(xmlSetter).
+			BodyString("fun=301&wlSsid2g=home$").
+			Reply(http.StatusOK)
+
+		_, err = client.SetRaw(context.Background(), FnSetWirelessBasic, [][2]string{{"wlSsid2g", "home"}})
+		require.NoError(t, err)
+		require.True(t, gock.IsDone())
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		defer gock.Off()
+
+		p := policy
+		p.InitialBackoff = time.Hour
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty", WithRetry(p))
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=134$").
+			Reply(http.StatusInternalServerError)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		var data MTUSize
+		err = client.Get(ctx, FnMTUSize, &data)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	require.Equal(t, 100*time.Millisecond, p.backoff(1))
+	require.Equal(t, 200*time.Millisecond, p.backoff(2))
+	require.Equal(t, 800*time.Millisecond, p.backoff(4))
+	require.Equal(t, time.Second, p.backoff(5))
+	require.Equal(t, time.Second, p.backoff(100))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(2)
+		require.GreaterOrEqual(t, d, 100*time.Millisecond)
+		require.LessOrEqual(t, d, 200*time.Millisecond)
+	}
+}
@@ -0,0 +1,330 @@
+// Package sagemcom provides a client for Sagemcom F@st cable modems (e.g.
+// F@st 3890 and 3896), that are managed through JSON REST API at /rest/v1.
+//
+// The client implements connectbox.Modem, so tools built for ConnectBox
+// work with these modems too.
+package sagemcom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetafro/connectbox"
+)
+
+// List of REST API endpoints.
+const (
+	pathLogin      = "/rest/v1/user/login"
+	pathLogout     = "/rest/v1/user/%d/token/%s"
+	pathSystemInfo = "/rest/v1/system/info"
+	pathState      = "/rest/v1/cablemodem/state_"
+	pathDownstream = "/rest/v1/cablemodem/downstream"
+	pathUpstream   = "/rest/v1/cablemodem/upstream"
+	pathEventLog   = "/rest/v1/cablemodem/eventlog"
+	pathHosts      = "/rest/v1/network/hosts?connectedOnly=true"
+	pathReboot     = "/rest/v1/system/reboot"
+)
+
+// statusOperational is a cable modem status when it's online.
+const statusOperational = "operational"
+
+// Client is a client for Sagemcom REST API.
+type Client struct {
+	http     *http.Client
+	addr     string
+	password string
+
+	// mu guards the session
+	mu     sync.Mutex
+	token  string
+	userID int
+}
+
+var _ connectbox.Modem = (*Client)(nil)
+
+// NewClient creates new Sagemcom client. Only the admin password is
+// needed, the username is fixed.
+func NewClient(addr, password string) (*Client, error) {
+	if !strings.HasPrefix(addr, "http") {
+		addr = "http://" + addr
+	}
+
+	_, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address: %s", addr)
+	}
+
+	return &Client{
+		http:     &http.Client{},
+		addr:     strings.TrimSuffix(addr, "/"),
+		password: password,
+	}, nil
+}
+
+// NewModem creates a client for the fleet device, it's a driver for
+// connectbox.FleetConfig.
+func NewModem(d connectbox.Device) (connectbox.Modem, error) {
+	client, err := NewClient(d.Addr, d.Password)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Login creates a session, the token is used for all further requests.
+func (c *Client) Login(ctx context.Context) error {
+	req := map[string]string{"password": c.password}
+	var resp struct {
+		Created struct {
+			Token  string `json:"token"`
+			UserID int    `json:"userId"`
+		} `json:"created"`
+	}
+	if err := c.request(ctx, http.MethodPost, pathLogin, req, &resp); err != nil {
+		return err
+	}
+	if resp.Created.Token == "" {
+		return fmt.Errorf("missing token")
+	}
+
+	c.mu.Lock()
+	c.token = resp.Created.Token
+	c.userID = resp.Created.UserID
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Logout closes current session.
+func (c *Client) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	path := fmt.Sprintf(pathLogout, c.userID, url.PathEscape(c.token))
+	c.mu.Unlock()
+
+	if err := c.request(ctx, http.MethodDelete, path, nil, nil); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.token = ""
+	c.mu.Unlock()
+
+	return nil
+}
+
+// ModemStatus reads the modem details and its online state.
+func (c *Client) ModemStatus(ctx context.Context) (*connectbox.ModemStatus, error) {
+	var info struct {
+		Info struct {
+			ModelName       string `json:"modelName"`
+			SoftwareVersion string `json:"softwareVersion"`
+			HardwareVersion string `json:"hardwareVersion"`
+			SerialNumber    string `json:"serialNumber"`
+			MACAddress      string `json:"macAddress"`
+		} `json:"info"`
+	}
+	if err := c.request(ctx, http.MethodGet, pathSystemInfo, nil, &info); err != nil {
+		return nil, fmt.Errorf("get system info: %w", err)
+	}
+	var state struct {
+		CableModem struct {
+			DocsisVersion string `json:"docsisVersion"`
+			Status        string `json:"status"`
+			UpTime        int    `json:"upTime"`
+			AccessAllowed bool   `json:"accessAllowed"`
+			WANIPv4Addr   string `json:"wanIpv4Address"`
+		} `json:"cablemodem"`
+	}
+	if err := c.request(ctx, http.MethodGet, pathState, nil, &state); err != nil {
+		return nil, fmt.Errorf("get cable modem state: %w", err)
+	}
+
+	cm := state.CableModem
+	mode, err := connectbox.ParseDocsisMode(cm.DocsisVersion)
+	if err != nil {
+		mode = connectbox.DocsisMode(cm.DocsisVersion)
+	}
+	return &connectbox.ModemStatus{
+		Model:           info.Info.ModelName,
+		SoftwareVersion: info.Info.SoftwareVersion,
+		HardwareVersion: info.Info.HardwareVersion,
+		SerialNumber:    info.Info.SerialNumber,
+		MACAddr:         info.Info.MACAddress,
+		DocsisMode:      mode,
+		Uptime:          time.Duration(cm.UpTime) * time.Second,
+		Online:          cm.Status == statusOperational && cm.AccessAllowed,
+		WANIPv4Addr:     cm.WANIPv4Addr,
+	}, nil
+}
+
+// channel is a channel format for both downstream and upstream.
+type channel struct {
+	ChannelID         int     `json:"channelId"`
+	Frequency         int64   `json:"frequency"`
+	Power             float64 `json:"power"`
+	RxMER             float64 `json:"rxMer"`
+	Modulation        string  `json:"modulation"`
+	LockStatus        bool    `json:"lockStatus"`
+	CorrectedErrors   int64   `json:"correctedErrors"`
+	UncorrectedErrors int64   `json:"uncorrectedErrors"`
+}
+
+// Channels reads downstream and upstream channels.
+func (c *Client) Channels(ctx context.Context) (*connectbox.Channels, error) {
+	var ds struct {
+		Downstream struct {
+			Channels []channel `json:"channels"`
+		} `json:"downstream"`
+	}
+	if err := c.request(ctx, http.MethodGet, pathDownstream, nil, &ds); err != nil {
+		return nil, fmt.Errorf("get downstream: %w", err)
+	}
+	var us struct {
+		Upstream struct {
+			Channels []channel `json:"channels"`
+		} `json:"upstream"`
+	}
+	if err := c.request(ctx, http.MethodGet, pathUpstream, nil, &us); err != nil {
+		return nil, fmt.Errorf("get upstream: %w", err)
+	}
+
+	channels := &connectbox.Channels{}
+	for _, ch := range ds.Downstream.Channels {
+		channels.Downstream = append(channels.Downstream, convertChannel(ch))
+	}
+	for _, ch := range us.Upstream.Channels {
+		channels.Upstream = append(channels.Upstream, convertChannel(ch))
+	}
+	return channels, nil
+}
+
+// Events reads the modem event log.
+func (c *Client) Events(ctx context.Context) ([]connectbox.Event, error) {
+	var resp struct {
+		EventLog []struct {
+			Time     time.Time `json:"time"`
+			Priority string    `json:"priority"`
+			Message  string    `json:"message"`
+		} `json:"eventlog"`
+	}
+	if err := c.request(ctx, http.MethodGet, pathEventLog, nil, &resp); err != nil {
+		return nil, fmt.Errorf("get event log: %w", err)
+	}
+	events := make([]connectbox.Event, 0, len(resp.EventLog))
+	for _, e := range resp.EventLog {
+		events = append(events, connectbox.Event{
+			Time:     e.Time,
+			Priority: e.Priority,
+			Text:     e.Message,
+		})
+	}
+	return events, nil
+}
+
+// Clients reads the list of devices connected to the LAN.
+func (c *Client) Clients(ctx context.Context) ([]connectbox.LANClient, error) {
+	var resp struct {
+		Hosts struct {
+			Hosts []struct {
+				MACAddress string `json:"macAddress"`
+				Config     struct {
+					Hostname  string `json:"hostname"`
+					Interface string `json:"interface"`
+					IPv4      struct {
+						Address string `json:"address"`
+					} `json:"ipv4"`
+				} `json:"config"`
+			} `json:"hosts"`
+		} `json:"hosts"`
+	}
+	if err := c.request(ctx, http.MethodGet, pathHosts, nil, &resp); err != nil {
+		return nil, fmt.Errorf("get hosts: %w", err)
+	}
+	clients := make([]connectbox.LANClient, 0, len(resp.Hosts.Hosts))
+	for _, h := range resp.Hosts.Hosts {
+		clients = append(clients, connectbox.LANClient{
+			Hostname: h.Config.Hostname,
+			MACAddr:  h.MACAddress,
+			IPAddr:   h.Config.IPv4.Address,
+			Wireless: strings.EqualFold(h.Config.Interface, "wifi"),
+		})
+	}
+	return clients, nil
+}
+
+// Reboot restarts the modem. The session is lost, and Login must be
+// called again after the modem is back.
+func (c *Client) Reboot(ctx context.Context) error {
+	req := map[string]any{"reboot": map[string]bool{"enable": true}}
+	return c.request(ctx, http.MethodPost, pathReboot, req, nil)
+}
+
+// request sends a request with optional JSON body, and decodes JSON
+// response into out, if it's not nil.
+func (c *Client) request(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+path, body)
+	if err != nil {
+		return fmt.Errorf("init request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.mu.Lock()
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	c.mu.Unlock()
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &connectbox.StatusError{Code: resp.StatusCode}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func convertChannel(ch channel) connectbox.Channel {
+	mod, err := connectbox.ParseModulation(ch.Modulation)
+	if err != nil {
+		mod = connectbox.Modulation(ch.Modulation)
+	}
+	return connectbox.Channel{
+		ID:            strconv.Itoa(ch.ChannelID),
+		Frequency:     ch.Frequency,
+		Power:         ch.Power,
+		SNR:           ch.RxMER,
+		Modulation:    mod,
+		Locked:        ch.LockStatus,
+		Correctable:   ch.CorrectedErrors,
+		Uncorrectable: ch.UncorrectedErrors,
+	}
+}
@@ -0,0 +1,330 @@
+package sagemcom
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tetafro/connectbox"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		client, err := NewClient("192.168.100.1/", "secret")
+		require.NoError(t, err)
+		require.Equal(t, "http://192.168.100.1", client.addr)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := NewClient("hello, world!", "secret")
+		require.ErrorContains(t, err, "invalid address")
+	})
+}
+
+func TestNewModem(t *testing.T) {
+	modem := newFakeModem(t)
+	f, err := connectbox.NewFleet(connectbox.FleetConfig{
+		Devices: []connectbox.Device{
+			{Name: "home", Model: "sagemcom", Addr: modem.srv.URL, Password: "secret"},
+		},
+		Drivers: map[string]connectbox.ModemFactory{"sagemcom": NewModem},
+	})
+	require.NoError(t, err)
+	defer f.Close()
+
+	results := connectbox.Poll(context.Background(), f,
+		func(ctx context.Context, m connectbox.Modem) (bool, error) {
+			_, ok := m.(*Client)
+			return ok, nil
+		})
+	require.Equal(t, map[string]connectbox.FleetResult[bool]{
+		"home": {Value: true},
+	}, results)
+}
+
+func TestClient(t *testing.T) {
+	modem := newFakeModem(t)
+	client, err := NewClient(modem.srv.URL, "secret")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("no session", func(t *testing.T) {
+		_, err := client.ModemStatus(ctx)
+		require.EqualError(t, err, "get system info: invalid response status: 401")
+	})
+
+	t.Run("login", func(t *testing.T) {
+		require.NoError(t, client.Login(ctx))
+		require.Equal(t, "token1", client.token)
+		require.Equal(t, 3, client.userID)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		status, err := client.ModemStatus(ctx)
+		require.NoError(t, err)
+		require.Equal(t, &connectbox.ModemStatus{
+			Model:           "F3896LG",
+			SoftwareVersion: "LG-RDK_6.11.1-2201",
+			HardwareVersion: "2.1",
+			SerialNumber:    "DEAP1300000A",
+			MACAddr:         "00:11:22:33:44:55",
+			DocsisMode:      connectbox.Docsis31,
+			Uptime:          405035 * time.Second,
+			Online:          true,
+			WANIPv4Addr:     "1.2.3.4",
+		}, status)
+	})
+
+	t.Run("channels", func(t *testing.T) {
+		channels, err := client.Channels(ctx)
+		require.NoError(t, err)
+		require.Equal(t, &connectbox.Channels{
+			Downstream: []connectbox.Channel{
+				{
+					ID:            "1",
+					Frequency:     826000000,
+					Power:         6.2,
+					SNR:           38.7,
+					Modulation:    connectbox.Modulation256QAM,
+					Locked:        true,
+					Correctable:   12,
+					Uncorrectable: 1,
+				},
+				{
+					ID:         "33",
+					Frequency:  135000000,
+					Power:      3,
+					SNR:        41,
+					Modulation: connectbox.ModulationOFDM,
+					Locked:     false,
+				},
+			},
+			Upstream: []connectbox.Channel{
+				{
+					ID:         "2",
+					Frequency:  49600000,
+					Power:      44.5,
+					Modulation: connectbox.Modulation64QAM,
+					Locked:     true,
+				},
+			},
+		}, channels)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		events, err := client.Events(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []connectbox.Event{{
+			Time:     time.Date(2023, 9, 20, 14, 40, 41, 0, time.UTC),
+			Priority: "notice",
+			Text:     "Cable Modem Reboot",
+		}}, events)
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		clients, err := client.Clients(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []connectbox.LANClient{
+			{
+				Hostname: "laptop",
+				MACAddr:  "00:11:22:33:44:66",
+				IPAddr:   "192.168.0.10",
+				Wireless: true,
+			},
+			{
+				Hostname: "tv",
+				MACAddr:  "00:11:22:33:44:77",
+				IPAddr:   "192.168.0.11",
+			},
+		}, clients)
+	})
+
+	t.Run("reboot", func(t *testing.T) {
+		require.NoError(t, client.Reboot(ctx))
+		require.Equal(t, `{"reboot":{"enable":true}}`, modem.lastBody())
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		require.NoError(t, client.Logout(ctx))
+		require.Empty(t, client.token)
+
+		_, err := client.Clients(ctx)
+		require.EqualError(t, err, "get hosts: invalid response status: 401")
+	})
+
+	t.Run("invalid password", func(t *testing.T) {
+		client, err := NewClient(modem.srv.URL, "qwerty")
+		require.NoError(t, err)
+		require.EqualError(t, client.Login(ctx), "invalid response status: 401")
+	})
+}
+
+// fakeModem is a fake Sagemcom REST API server.
+type fakeModem struct {
+	srv *httptest.Server
+
+	mu    sync.Mutex
+	token string
+	body  string
+}
+
+func newFakeModem(t *testing.T) *fakeModem {
+	t.Helper()
+
+	m := &fakeModem{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(pathLogin, m.login)
+	mux.HandleFunc("/rest/v1/user/3/token/", m.logout)
+	m.handle(mux, http.MethodGet, pathSystemInfo, `{"info": {
+		"modelName": "F3896LG",
+		"softwareVersion": "LG-RDK_6.11.1-2201",
+		"hardwareVersion": "2.1",
+		"serialNumber": "DEAP1300000A",
+		"macAddress": "00:11:22:33:44:55"
+	}}`)
+	m.handle(mux, http.MethodGet, pathState, `{"cablemodem": {
+		"docsisVersion": "3.1",
+		"status": "operational",
+		"upTime": 405035,
+		"accessAllowed": true,
+		"wanIpv4Address": "1.2.3.4"
+	}}`)
+	m.handle(mux, http.MethodGet, pathDownstream, `{"downstream": {"channels": [
+		{
+			"channelId": 1,
+			"frequency": 826000000,
+			"power": 6.2,
+			"rxMer": 38.7,
+			"modulation": "qam_256",
+			"lockStatus": true,
+			"correctedErrors": 12,
+			"uncorrectedErrors": 1
+		},
+		{
+			"channelId": 33,
+			"frequency": 135000000,
+			"power": 3,
+			"rxMer": 41,
+			"modulation": "ofdm",
+			"lockStatus": false
+		}
+	]}}`)
+	m.handle(mux, http.MethodGet, pathUpstream, `{"upstream": {"channels": [
+		{
+			"channelId": 2,
+			"frequency": 49600000,
+			"power": 44.5,
+			"modulation": "qam_64",
+			"lockStatus": true
+		}
+	]}}`)
+	m.handle(mux, http.MethodGet, pathEventLog, `{"eventlog": [
+		{
+			"time": "2023-09-20T14:40:41Z",
+			"priority": "notice",
+			"message": "Cable Modem Reboot"
+		}
+	]}`)
+	m.handle(mux, http.MethodGet, "/rest/v1/network/hosts", `{"hosts": {"hosts": [
+		{
+			"macAddress": "00:11:22:33:44:66",
+			"config": {
+				"hostname": "laptop",
+				"interface": "wifi",
+				"ipv4": {"address": "192.168.0.10"}
+			}
+		},
+		{
+			"macAddress": "00:11:22:33:44:77",
+			"config": {
+				"hostname": "tv",
+				"interface": "ethernet",
+				"ipv4": {"address": "192.168.0.11"}
+			}
+		}
+	]}}`)
+	m.handle(mux, http.MethodPost, pathReboot, "")
+
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *fakeModem) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if req.Password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	m.mu.Lock()
+	m.token = "token1"
+	m.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.WriteString(w, `{"created": {"token": "token1", "userId": 3, "userLevel": "admin"}}`)
+}
+
+func (m *fakeModem) logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token == "" || path.Base(r.URL.Path) != m.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	m.token = ""
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handle registers a handler, that checks the session and responds with
+// the static body.
+func (m *fakeModem) handle(mux *http.ServeMux, method, pattern, resp string) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if m.token == "" || r.Header.Get("Authorization") != "Bearer "+m.token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		m.body = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, resp)
+	})
+}
+
+func (m *fakeModem) lastBody() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.body
+}
@@ -0,0 +1,87 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+)
+
+// Session keeps one router session for long-running services, that make
+// requests on behalf of others (gateway, proxy, MQTT bridge, fleet). It
+// logs in lazily before the first request, and the router may close the
+// session at any time, so failed requests follow the same rules
+// everywhere:
+//   - Read requests are retried once after a new login.
+//   - Write requests are never retried, because a failed one may still
+//     have been applied (e.g. reboot). The next request logs in again.
+//
+// Session is not safe for concurrent use, callers must serialize requests
+// to the router anyway, because the session token changes after each
+// request.
+type Session struct {
+	login    func(ctx context.Context) error
+	loggedIn bool
+}
+
+// NewSession creates new session, that uses login function to log in
+// to the router, e.g. Client.Login.
+func NewSession(login func(ctx context.Context) error) *Session {
+	return &Session{login: login}
+}
+
+// Read runs a request, that doesn't change the router state. It's retried
+// once after a new login if it fails.
+func (s *Session) Read(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := s.ensure(ctx); err != nil {
+		return err
+	}
+	if err := fn(ctx); err == nil {
+		return nil
+	}
+	if err := s.relogin(ctx); err != nil {
+		return err
+	}
+	if err := fn(ctx); err != nil {
+		s.loggedIn = false
+		return err
+	}
+	return nil
+}
+
+// Write runs a request, that changes the router state. It's not retried,
+// the session is reset on failure instead.
+func (s *Session) Write(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := s.ensure(ctx); err != nil {
+		return err
+	}
+	if err := fn(ctx); err != nil {
+		s.loggedIn = false
+		return err
+	}
+	return nil
+}
+
+// LoggedIn reports whether the session is open, i.e. logout is needed.
+func (s *Session) LoggedIn() bool {
+	return s.loggedIn
+}
+
+// Reset forgets the session, the next request logs in again.
+func (s *Session) Reset() {
+	s.loggedIn = false
+}
+
+func (s *Session) ensure(ctx context.Context) error {
+	if s.loggedIn {
+		return nil
+	}
+	return s.relogin(ctx)
+}
+
+func (s *Session) relogin(ctx context.Context) error {
+	s.loggedIn = false
+	if err := s.login(ctx); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	s.loggedIn = true
+	return nil
+}
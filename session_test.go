@@ -0,0 +1,104 @@
+package connectbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession_Read(t *testing.T) {
+	t.Run("lazy login", func(t *testing.T) {
+		var logins, calls int
+		s := NewSession(func(context.Context) error { logins++; return nil })
+		require.False(t, s.LoggedIn())
+
+		for i := 0; i < 2; i++ {
+			err := s.Read(context.Background(), func(context.Context) error {
+				calls++
+				return nil
+			})
+			require.NoError(t, err)
+		}
+		require.Equal(t, 1, logins)
+		require.Equal(t, 2, calls)
+		require.True(t, s.LoggedIn())
+	})
+
+	t.Run("retry after login", func(t *testing.T) {
+		var logins, calls int
+		s := NewSession(func(context.Context) error { logins++; return nil })
+
+		err := s.Read(context.Background(), func(context.Context) error {
+			calls++
+			if calls == 1 {
+				return errors.New("session closed")
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, logins)
+		require.Equal(t, 2, calls)
+		require.True(t, s.LoggedIn())
+	})
+
+	t.Run("retry fails", func(t *testing.T) {
+		var calls int
+		s := NewSession(func(context.Context) error { return nil })
+
+		err := s.Read(context.Background(), func(context.Context) error {
+			calls++
+			return errors.New("fail")
+		})
+		require.EqualError(t, err, "fail")
+		require.Equal(t, 2, calls)
+		require.False(t, s.LoggedIn())
+	})
+
+	t.Run("failed login", func(t *testing.T) {
+		s := NewSession(func(context.Context) error { return errors.New("denied") })
+
+		err := s.Read(context.Background(), func(context.Context) error {
+			t.Fatal("request without session")
+			return nil
+		})
+		require.EqualError(t, err, "login: denied")
+		require.False(t, s.LoggedIn())
+	})
+}
+
+func TestSession_Write(t *testing.T) {
+	t.Run("not retried", func(t *testing.T) {
+		var logins, calls int
+		s := NewSession(func(context.Context) error { logins++; return nil })
+
+		err := s.Write(context.Background(), func(context.Context) error {
+			calls++
+			return errors.New("fail")
+		})
+		require.EqualError(t, err, "fail")
+		require.Equal(t, 1, logins)
+		require.Equal(t, 1, calls)
+		require.False(t, s.LoggedIn())
+
+		// Next request logs in again
+		err = s.Write(context.Background(), func(context.Context) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, logins)
+		require.True(t, s.LoggedIn())
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		s := NewSession(func(context.Context) error { return nil })
+		require.NoError(t, s.Write(context.Background(), func(context.Context) error {
+			return nil
+		}))
+		require.True(t, s.LoggedIn())
+
+		s.Reset()
+		require.False(t, s.LoggedIn())
+	})
+}
@@ -0,0 +1,324 @@
+package connectbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// storeFileLayout is a date format used for store file names, one file
+// holds samples for one day (UTC).
+const (
+	storeFileLayout = "2006-01-02"
+	storeFileExt    = ".jsonl"
+)
+
+// FileStoreConfig is a configuration of the file store.
+type FileStoreConfig struct {
+	// Retention is how long samples are kept, 0 means forever
+	Retention time.Duration
+	// DownsampleAfter is the age after which samples are downsampled,
+	// 0 disables downsampling
+	DownsampleAfter time.Duration
+	// DownsampleStep is the interval of averaged samples after
+	// downsampling
+	DownsampleStep time.Duration
+}
+
+// FileStore is an append-only store of samples. Samples are saved to
+// the directory as JSON lines, one file per day.
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+	cfg FileStoreConfig
+	// compacted is a set of files that are already downsampled
+	compacted map[string]bool
+}
+
+// NewFileStore creates new file store in the directory. The directory is
+// created if it doesn't exist.
+func NewFileStore(dir string, cfg FileStoreConfig) (*FileStore, error) {
+	if cfg.DownsampleAfter > 0 && cfg.DownsampleStep <= 0 {
+		return nil, fmt.Errorf("invalid downsample step: %s", cfg.DownsampleStep)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	return &FileStore{
+		dir:       dir,
+		cfg:       cfg,
+		compacted: map[string]bool{},
+	}, nil
+}
+
+// Append saves samples to the store.
+func (s *FileStore) Append(samples []Sample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byFile := map[string][]Sample{}
+	for _, sample := range samples {
+		name := s.fileName(sample.Time)
+		byFile[name] = append(byFile[name], sample)
+	}
+	for name, samples := range byFile {
+		if err := appendSamples(name, samples); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query returns samples of the metric for the channel within the time
+// range [from, to). Empty channel matches all channels. Samples are
+// sorted by time.
+func (s *FileStore) Query(metric, channel string, from, to time.Time) ([]Sample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Sample
+	for _, f := range files {
+		day, err := fileDay(f)
+		if err != nil {
+			continue
+		}
+		if !day.Before(to) || !day.Add(24*time.Hour).After(from) {
+			continue
+		}
+		samples, err := readSamples(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range samples {
+			if sample.Metric != metric {
+				continue
+			}
+			if channel != "" && sample.Channel != channel {
+				continue
+			}
+			if sample.Time.Before(from) || !sample.Time.Before(to) {
+				continue
+			}
+			result = append(result, sample)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// Compact removes files older than retention period, and downsamples
+// files older than downsampling age.
+func (s *FileStore) Compact(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		day, err := fileDay(f)
+		if err != nil {
+			continue
+		}
+		// File holds samples until the end of the day
+		age := now.Sub(day.Add(24 * time.Hour))
+
+		if s.cfg.Retention > 0 && age > s.cfg.Retention {
+			if err := os.Remove(f); err != nil {
+				return fmt.Errorf("remove file: %w", err)
+			}
+			delete(s.compacted, f)
+			continue
+		}
+		if s.cfg.DownsampleAfter > 0 && age > s.cfg.DownsampleAfter && !s.compacted[f] {
+			if err := downsampleFile(f, s.cfg.DownsampleStep); err != nil {
+				return err
+			}
+			s.compacted[f] = true
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) fileName(t time.Time) string {
+	return filepath.Join(s.dir, t.UTC().Format(storeFileLayout)+storeFileExt)
+}
+
+// files returns the list of store files sorted by date.
+func (s *FileStore) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+storeFileExt))
+	if err != nil {
+		return nil, fmt.Errorf("list files: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// fileDay returns the day of the samples in the file.
+func fileDay(name string) (time.Time, error) {
+	return time.Parse(storeFileLayout, strings.TrimSuffix(filepath.Base(name), storeFileExt)) //nolint:wrapcheck
+}
+
+func appendSamples(name string, samples []Sample) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	if err := trimPartialLine(f); err != nil {
+		f.Close()
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, sample := range samples {
+		if err := enc.Encode(sample); err != nil {
+			f.Close()
+			return fmt.Errorf("encode sample: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close file: %w", err)
+	}
+	return nil
+}
+
+func readSamples(name string) ([]Sample, error) {
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	var samples []Sample
+	r := bufio.NewReader(f)
+	for {
+		line, rerr := r.ReadBytes('\n')
+		if rerr != nil && !errors.Is(rerr, io.EOF) {
+			return nil, fmt.Errorf("read file: %w", rerr)
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			var sample Sample
+			err := json.Unmarshal(line, &sample)
+			if err != nil && rerr == nil {
+				return nil, fmt.Errorf("decode sample from %s: %w", filepath.Base(name), err)
+			}
+			// The last line without a newline may be left incomplete by
+			// an interrupted write, it's skipped
+			if err == nil {
+				samples = append(samples, sample)
+			}
+		}
+		if rerr != nil {
+			return samples, nil
+		}
+	}
+}
+
+// partialLineTail is a size of the file tail, that is checked for
+// an incomplete last line. Sample lines are much shorter.
+const partialLineTail = 4096
+
+// trimPartialLine removes an incomplete last line left by an interrupted
+// write, so that new samples start on a new line.
+func trimPartialLine(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat file: %w", err)
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil
+	}
+	tail := make([]byte, min(size, partialLineTail))
+	offset := size - int64(len(tail))
+	if _, err := f.ReadAt(tail, offset); err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+	if tail[len(tail)-1] == '\n' {
+		return nil
+	}
+	// Without a newline in the tail, the whole tail is dropped
+	keep := offset + int64(bytes.LastIndexByte(tail, '\n')+1)
+	if err := f.Truncate(keep); err != nil {
+		return fmt.Errorf("truncate file: %w", err)
+	}
+	return nil
+}
+
+// downsampleFile replaces samples in the file with their averages over
+// `step` intervals, separately for each metric and channel.
+func downsampleFile(name string, step time.Duration) error {
+	samples, err := readSamples(name)
+	if err != nil {
+		return err
+	}
+
+	type key struct {
+		metric  string
+		channel string
+		bucket  time.Time
+	}
+	type agg struct {
+		sum   float64
+		count int
+	}
+	var keys []key
+	aggs := map[key]*agg{}
+	for _, sample := range samples {
+		k := key{sample.Metric, sample.Channel, sample.Time.Truncate(step)}
+		a, ok := aggs[k]
+		if !ok {
+			a = &agg{}
+			aggs[k] = a
+			keys = append(keys, k)
+		}
+		a.sum += sample.Value
+		a.count++
+	}
+
+	result := make([]Sample, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, Sample{
+			Time:    k.bucket,
+			Metric:  k.metric,
+			Channel: k.channel,
+			Value:   aggs[k].sum / float64(aggs[k].count),
+		})
+	}
+
+	tmp := name + ".tmp"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove temporary file: %w", err)
+	}
+	if err := appendSamples(tmp, result); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		return fmt.Errorf("replace file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package connectbox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore_Query(t *testing.T) {
+	store, err := NewFileStore(t.TempDir(), FileStoreConfig{})
+	require.NoError(t, err)
+
+	day1 := time.Date(2023, 9, 20, 23, 59, 0, 0, time.UTC)
+	day2 := time.Date(2023, 9, 21, 0, 1, 0, 0, time.UTC)
+	err = store.Append([]Sample{
+		{Time: day2, Metric: MetricDownstreamPower, Channel: "1", Value: 5},
+		{Time: day1, Metric: MetricDownstreamPower, Channel: "1", Value: 6},
+		{Time: day1, Metric: MetricDownstreamPower, Channel: "2", Value: 7},
+		{Time: day1, Metric: MetricTemperature, Value: 40},
+	})
+	require.NoError(t, err)
+
+	t.Run("metric and channel", func(t *testing.T) {
+		samples, err := store.Query(MetricDownstreamPower, "1", day1, day2.Add(time.Second))
+		require.NoError(t, err)
+		require.Equal(t, []Sample{
+			{Time: day1, Metric: MetricDownstreamPower, Channel: "1", Value: 6},
+			{Time: day2, Metric: MetricDownstreamPower, Channel: "1", Value: 5},
+		}, samples)
+	})
+
+	t.Run("all channels", func(t *testing.T) {
+		samples, err := store.Query(MetricDownstreamPower, "", day1, day2)
+		require.NoError(t, err)
+		require.Equal(t, []Sample{
+			{Time: day1, Metric: MetricDownstreamPower, Channel: "1", Value: 6},
+			{Time: day1, Metric: MetricDownstreamPower, Channel: "2", Value: 7},
+		}, samples)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		samples, err := store.Query(MetricTemperature, "", day2, day2.Add(time.Hour))
+		require.NoError(t, err)
+		require.Empty(t, samples)
+	})
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, FileStoreConfig{
+		Retention:       7 * 24 * time.Hour,
+		DownsampleAfter: 24 * time.Hour,
+		DownsampleStep:  time.Hour,
+	})
+	require.NoError(t, err)
+
+	now := time.Date(2023, 9, 30, 12, 0, 0, 0, time.UTC)
+	expired := time.Date(2023, 9, 20, 10, 0, 0, 0, time.UTC)
+	old := time.Date(2023, 9, 28, 10, 0, 0, 0, time.UTC)
+	err = store.Append([]Sample{
+		{Time: expired, Metric: MetricTemperature, Value: 40},
+		{Time: old, Metric: MetricTemperature, Value: 40},
+		{Time: old.Add(10 * time.Minute), Metric: MetricTemperature, Value: 44},
+		{Time: old.Add(70 * time.Minute), Metric: MetricTemperature, Value: 50},
+		{Time: now, Metric: MetricTemperature, Value: 41},
+		{Time: now.Add(time.Minute), Metric: MetricTemperature, Value: 43},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Compact(now))
+	// Second compaction must not change anything
+	require.NoError(t, store.Compact(now))
+
+	_, err = os.Stat(filepath.Join(dir, "2023-09-20.jsonl"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	samples, err := store.Query(MetricTemperature, "", expired, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []Sample{
+		{Time: old, Metric: MetricTemperature, Value: 42},
+		{Time: old.Add(time.Hour), Metric: MetricTemperature, Value: 50},
+		{Time: now, Metric: MetricTemperature, Value: 41},
+		{Time: now.Add(time.Minute), Metric: MetricTemperature, Value: 43},
+	}, samples)
+}
+
+func TestFileStore_IncompleteLine(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, FileStoreConfig{
+		DownsampleAfter: 24 * time.Hour,
+		DownsampleStep:  time.Hour,
+	})
+	require.NoError(t, err)
+
+	day := time.Date(2023, 9, 20, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Append([]Sample{
+		{Time: day, Metric: MetricTemperature, Value: 40},
+	}))
+
+	// Write is interrupted by a crash
+	name := filepath.Join(dir, "2023-09-20.jsonl")
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"time":"2023-09-20T10:01:00Z","met`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	samples, err := store.Query(MetricTemperature, "", day, day.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []Sample{{Time: day, Metric: MetricTemperature, Value: 40}}, samples)
+
+	// New samples don't continue the broken line
+	require.NoError(t, store.Append([]Sample{
+		{Time: day.Add(2 * time.Minute), Metric: MetricTemperature, Value: 44},
+	}))
+	samples, err = store.Query(MetricTemperature, "", day, day.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []Sample{
+		{Time: day, Metric: MetricTemperature, Value: 40},
+		{Time: day.Add(2 * time.Minute), Metric: MetricTemperature, Value: 44},
+	}, samples)
+
+	require.NoError(t, store.Compact(day.Add(48*time.Hour)))
+	samples, err = store.Query(MetricTemperature, "", day, day.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []Sample{{Time: day, Metric: MetricTemperature, Value: 42}}, samples)
+}
+
+func TestFileStore_BrokenLine(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, FileStoreConfig{})
+	require.NoError(t, err)
+
+	data := "{broken\n" + `{"time":"2023-09-20T10:00:00Z","metric":"temperature","value":40}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2023-09-20.jsonl"), []byte(data), 0o600))
+
+	day := time.Date(2023, 9, 20, 0, 0, 0, 0, time.UTC)
+	_, err = store.Query(MetricTemperature, "", day, day.Add(24*time.Hour))
+	require.ErrorContains(t, err, "decode sample from 2023-09-20.jsonl")
+}
+
+func TestNewFileStore(t *testing.T) {
+	_, err := NewFileStore(t.TempDir(), FileStoreConfig{DownsampleAfter: time.Hour})
+	require.ErrorContains(t, err, "invalid downsample step")
+}
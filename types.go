@@ -3,6 +3,7 @@ package connectbox
 import (
 	"encoding/xml"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"time"
@@ -10,34 +11,34 @@ import (
 
 // GlobalSettings is a response format for getter.xml/fn=1 endpoint.
 type GlobalSettings struct {
-	AccessLevel               string `xml:"AccessLevel"`
-	SwVersion                 string `xml:"SwVersion"`
-	CmProvisionMode           string `xml:"CmProvisionMode"`
-	DsLite                    string `xml:"DsLite"`
-	GwProvisionMode           string `xml:"GwProvisionMode"`
-	GwOperMode                string `xml:"GWOperMode"`
-	ConfigVenderModel         string `xml:"ConfigVenderModel"`
-	HideRemoteAccess          string `xml:"HideRemoteAccess"`
-	HideModemMode             string `xml:"HideModemMode"`
-	HideCustomerDHCPLANChange string `xml:"HideCustomerDhcpLanChange"`
-	ShowDDNS                  string `xml:"ShowDDNS"`
-	OperatorID                string `xml:"OperatorId"`
-	AccessDenied              string `xml:"AccessDenied"`
-	LockedOut                 string `xml:"LockedOut"`
-	CountryID                 string `xml:"CountryID"`
-	Title                     string `xml:"title"`
-	Interface                 string `xml:"Interface"`
-	OperStatus                string `xml:"operStatus"`
+	AccessLevel               string        `xml:"AccessLevel"`
+	SwVersion                 string        `xml:"SwVersion"`
+	CmProvisionMode           ProvisionMode `xml:"CmProvisionMode"`
+	DsLite                    string        `xml:"DsLite"`
+	GwProvisionMode           ProvisionMode `xml:"GwProvisionMode"`
+	GwOperMode                string        `xml:"GWOperMode"`
+	ConfigVenderModel         string        `xml:"ConfigVenderModel"`
+	HideRemoteAccess          string        `xml:"HideRemoteAccess"`
+	HideModemMode             string        `xml:"HideModemMode"`
+	HideCustomerDHCPLANChange string        `xml:"HideCustomerDhcpLanChange"`
+	ShowDDNS                  string        `xml:"ShowDDNS"`
+	OperatorID                string        `xml:"OperatorId"`
+	AccessDenied              string        `xml:"AccessDenied"`
+	LockedOut                 string        `xml:"LockedOut"`
+	CountryID                 string        `xml:"CountryID"`
+	Title                     string        `xml:"title"`
+	Interface                 string        `xml:"Interface"`
+	OperStatus                string        `xml:"operStatus"`
 }
 
 // CMSystemInfo is a response format for getter.xml/fn=2 endpoint.
 type CMSystemInfo struct {
-	DocsisMode      string `xml:"cm_docsis_mode"`
-	HardwareVersion string `xml:"cm_hardware_version"`
-	MacAddr         string `xml:"cm_mac_addr"`
-	SerialNumber    string `xml:"cm_serial_number"`
-	SystemUptime    int    `xml:"cm_system_uptime"`
-	NetworkAccess   string `xml:"cm_network_access"`
+	DocsisMode      DocsisMode `xml:"cm_docsis_mode"`
+	HardwareVersion string     `xml:"cm_hardware_version"`
+	MacAddr         string     `xml:"cm_mac_addr"`
+	SerialNumber    string     `xml:"cm_serial_number"`
+	SystemUptime    int        `xml:"cm_system_uptime"`
+	NetworkAccess   string     `xml:"cm_network_access"`
 }
 
 // UnmarshalXML adds string to seconds conversion.
@@ -56,7 +57,7 @@ func (c *CMSystemInfo) UnmarshalXML(d *xml.Decoder, start xml.StartElement) erro
 
 	dur, err := parseDuration(aux.SystemUptime)
 	if err != nil {
-		return err
+		return fmt.Errorf("cm_system_uptime: %w", err)
 	}
 	c.SystemUptime = int(dur.Seconds())
 
@@ -96,17 +97,17 @@ type DownstreamTable struct {
 
 // DownstreamTableDownstream is a part of DownstreamTable.
 type DownstreamTableDownstream struct {
-	Freq         string `xml:"freq"`
-	Pow          string `xml:"pow"`
-	Snr          string `xml:"snr"`
-	Mod          string `xml:"mod"`
-	Chid         string `xml:"chid"`
-	RxMER        string `xml:"RxMER"`
-	PreRs        string `xml:"PreRs"`
-	PostRs       string `xml:"PostRs"`
-	IsQamLocked  string `xml:"IsQamLocked"`
-	IsFECLocked  string `xml:"IsFECLocked"`
-	IsMpegLocked string `xml:"IsMpegLocked"`
+	Freq         string     `xml:"freq"`
+	Pow          string     `xml:"pow"`
+	Snr          string     `xml:"snr"`
+	Mod          Modulation `xml:"mod"`
+	Chid         string     `xml:"chid"`
+	RxMER        string     `xml:"RxMER"`
+	PreRs        string     `xml:"PreRs"`
+	PostRs       string     `xml:"PostRs"`
+	IsQamLocked  string     `xml:"IsQamLocked"`
+	IsFECLocked  string     `xml:"IsFECLocked"`
+	IsMpegLocked string     `xml:"IsMpegLocked"`
 }
 
 // UpstreamTable is a response format for getter.xml/fn=11 endpoint.
@@ -117,18 +118,18 @@ type UpstreamTable struct {
 
 // UpstreamTableUpstream is a part of UpstreamTable.
 type UpstreamTableUpstream struct {
-	Usid        string `xml:"usid"`
-	Freq        string `xml:"freq"`
-	Power       string `xml:"power"`
-	Srate       string `xml:"srate"`
-	Mod         string `xml:"mod"`
-	Ustype      string `xml:"ustype"`
-	T1Timeouts  string `xml:"t1Timeouts"`
-	T2Timeouts  string `xml:"t2Timeouts"`
-	T3Timeouts  string `xml:"t3Timeouts"`
-	T4Timeouts  string `xml:"t4Timeouts"`
-	Channeltype string `xml:"channeltype"`
-	MessageType string `xml:"messageType"`
+	Usid        string     `xml:"usid"`
+	Freq        string     `xml:"freq"`
+	Power       string     `xml:"power"`
+	Srate       string     `xml:"srate"`
+	Mod         Modulation `xml:"mod"`
+	Ustype      string     `xml:"ustype"`
+	T1Timeouts  string     `xml:"t1Timeouts"`
+	T2Timeouts  string     `xml:"t2Timeouts"`
+	T3Timeouts  string     `xml:"t3Timeouts"`
+	T4Timeouts  string     `xml:"t4Timeouts"`
+	Channeltype string     `xml:"channeltype"`
+	MessageType string     `xml:"messageType"`
 }
 
 // SignalTable is a response format for getter.xml/fn=12 endpoint.
@@ -435,7 +436,7 @@ type CMStatus struct {
 	Downstreams       []CMStatusDownstream  `xml:"downstream"`
 	UsNum             string                `xml:"us_num"`
 	Upstreams         []CMStatusUpstream    `xml:"upstream"`
-	CMDocsisMode      string                `xml:"cm_docsis_mode"`
+	CMDocsisMode      DocsisMode            `xml:"cm_docsis_mode"`
 	CMNetworkAccess   string                `xml:"cm_network_access"`
 	NumberOfCpes      string                `xml:"NumberOfCpes"`
 	DMaxCpes          string                `xml:"dMaxCpes"`
@@ -446,12 +447,12 @@ type CMStatus struct {
 
 // CMStatusDownstream is a part of CMStatus.
 type CMStatusDownstream struct {
-	Freq            string `xml:"freq"`
-	Mod             string `xml:"mod"`
-	Chid            string `xml:"chid"`
-	State           string `xml:"state"`
-	Status          string `xml:"status"`
-	PrimarySettings string `xml:"primarySettings"`
+	Freq            string     `xml:"freq"`
+	Mod             Modulation `xml:"mod"`
+	Chid            string     `xml:"chid"`
+	State           string     `xml:"state"`
+	Status          string     `xml:"status"`
+	PrimarySettings string     `xml:"primarySettings"`
 }
 
 // CMStatusUpstream is a part of CMStatus.
@@ -463,15 +464,16 @@ type CMStatusUpstream struct {
 	State string `xml:"state"`
 }
 
-// CMStatusServiceFlow is a part of CMStatus.
+// CMStatusServiceFlow is a part of CMStatus. Rates are in bits per second,
+// bursts are in bytes.
 type CMStatusServiceFlow struct {
-	Sfid             string `xml:"Sfid"`
-	Direction        string `xml:"direction"`
-	PMaxTrafficRate  string `xml:"pMaxTrafficRate"`
-	PMaxTrafficBurst string `xml:"pMaxTrafficBurst"`
-	PMinReservedRate string `xml:"pMinReservedRate"`
-	PMaxConcatBurst  string `xml:"pMaxConcatBurst"`
-	PSchedulingType  string `xml:"pSchedulingType"`
+	Sfid             string         `xml:"Sfid"`
+	Direction        FlowDirection  `xml:"direction"`
+	PMaxTrafficRate  int            `xml:"pMaxTrafficRate"`
+	PMaxTrafficBurst int            `xml:"pMaxTrafficBurst"`
+	PMinReservedRate int            `xml:"pMinReservedRate"`
+	PMaxConcatBurst  int            `xml:"pMaxConcatBurst"`
+	PSchedulingType  SchedulingType `xml:"pSchedulingType"`
 }
 
 // EthFlaplist is a response format for getter.xml/fn=147 endpoint.
@@ -785,25 +787,37 @@ type WirelessResetting struct {
 	IsWirelessResetting string `xml:"isWirelessResetting"`
 }
 
-var durationRegexp = regexp.MustCompile(`(?:(\d+)day\(s\))?(\d+)h:(\d+)m:(\d+)s`)
+var durationRegexp = regexp.MustCompile(`^(?:(\d+)day\(s\))?(\d+)h:(\d+)m:(\d+)s$`)
 
-// Input format: "1day(s)2h:34m:56s".
+// durationUnits are units of the duration string parts.
+var durationUnits = []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+// Input format: "1day(s)2h:34m:56s", days are optional, minutes and
+// seconds must be less than 60.
 func parseDuration(s string) (time.Duration, error) {
 	matches := durationRegexp.FindStringSubmatch(s)
 	if len(matches) != 5 {
-		return 0, fmt.Errorf("invalid duration string")
+		return 0, fmt.Errorf("invalid duration string: %q", s)
 	}
 
-	days, _ := strconv.Atoi(matches[1])
-	hours, _ := strconv.Atoi(matches[2])
-	minutes, _ := strconv.Atoi(matches[3])
-	seconds, _ := strconv.Atoi(matches[4])
-
-	dur := time.Duration(days)*24*time.Hour +
-		time.Duration(hours)*time.Hour +
-		time.Duration(minutes)*time.Minute +
-		time.Duration(seconds)*time.Second
-
+	var dur time.Duration
+	for i, unit := range durationUnits {
+		part := matches[i+1]
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration string: %q: %w", s, err)
+		}
+		if unit < time.Hour && n >= 60 {
+			return 0, fmt.Errorf("invalid duration string: %q: %d is out of range", s, n)
+		}
+		if n > int64(math.MaxInt64-dur)/int64(unit) {
+			return 0, fmt.Errorf("invalid duration string: %q: out of range", s)
+		}
+		dur += time.Duration(n) * unit
+	}
 	return dur, nil
 }
 
@@ -4,4 +4,9 @@ package connectbox
 const (
 	OperStateOK          = "OPERATIONAL"
 	NetworkAccessAllowed = "Allowed"
+	ProvisioningOnline   = "Online"
+	WirelessEnabled      = "1"
+	WirelessDisabled     = "2"
+	LockedOutEnabled     = "Enable"
+	LockedOutDisabled    = "Disable"
 )
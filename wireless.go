@@ -0,0 +1,25 @@
+package connectbox
+
+import (
+	"context"
+	"fmt"
+)
+
+// GuestNetwork returns true if the guest network is enabled on any band.
+func (z *Client) GuestNetwork(ctx context.Context) (bool, error) {
+	var gn WirelessGuestNetwork1
+	if err := z.Get(ctx, FnWirelessGuestNetwork1, &gn); err != nil {
+		return false, fmt.Errorf("get guest network: %w", err)
+	}
+	for _, i := range gn.Interfaces {
+		if i.Enable2G == WirelessEnabled {
+			return true, nil
+		}
+	}
+	for _, i := range gn.Interfaces5G {
+		if i.Enable5G == WirelessEnabled {
+			return true, nil
+		}
+	}
+	return false, nil
+}
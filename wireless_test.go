@@ -0,0 +1,57 @@
+package connectbox
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/h2non/gock"
+	"github.com/stretchr/testify/require"
+)
+
+const guestNetworkXML = `<WirelessGuestNetwork>
+	<MainEnable2G>1</MainEnable2G>
+	<MainEnable5G>1</MainEnable5G>
+	<Interface><Enable2G>2</Enable2G><BSSID2G>guest</BSSID2G></Interface>
+	<Interface5G><Enable5G>2</Enable5G><BSSID5G>guest</BSSID5G></Interface5G>
+</WirelessGuestNetwork>`
+
+func TestClient_GuestNetwork(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=307$").
+			Reply(http.StatusOK).
+			BodyString(guestNetworkXML)
+
+		enabled, err := client.GuestNetwork(context.Background())
+		require.NoError(t, err)
+		require.False(t, enabled)
+	})
+
+	t.Run("failed request", func(t *testing.T) {
+		defer gock.Off()
+
+		client, err := NewClient("http://127.0.0.1", "bob", "qwerty")
+		require.NoError(t, err)
+		client.token = "token1"
+
+		gock.InterceptClient(client.http)
+
+		gock.New("http://127.0.0.1").
+			Post(xmlGetter).
+			BodyString("fun=307$").
+			Reply(http.StatusInternalServerError)
+
+		_, err = client.GuestNetwork(context.Background())
+		require.ErrorContains(t, err, "get guest network")
+	})
+}